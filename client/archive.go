@@ -0,0 +1,593 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// How often products older than the retention period are removed
+	ArchivePruneInterval = time.Hour
+	// Default time products are kept in the archive
+	DefaultArchiveRetention = 7 * 24 * time.Hour
+
+	archiveFileLayout = "2006-01-02"
+	archiveFileExt    = ".jsonl"
+	archiveIndexExt   = ".index"
+)
+
+// ArchivedProduct is a received product along with its parsed metadata
+type ArchivedProduct struct {
+	ID          string // NWWS id attribute (processID.sequenceID)
+	Received    time.Time
+	Station     string
+	Ttaaii      string
+	AwipsID     string
+	Issue       string
+	Name        string
+	Category    string
+	DisplayName string
+	IsCAP       bool
+	Events      []string `json:",omitempty"` // VTEC event keys, see EventKey
+	UGC         []string `json:",omitempty"`
+	Heading     string   `json:",omitempty"` // WMO heading without the BBB indicator
+	BBB         string   `json:",omitempty"`
+	Text        string   `json:",omitempty"` // Empty in index files
+}
+
+// ArchiveQuery selects archived products. Empty fields match everything.
+type ArchiveQuery struct {
+	Station  string
+	AwipsID  string // Full AWIPS ID (e.g., TORDTX) or just the NNN (e.g., TOR)
+	Category string
+	EventKey string
+	Since    time.Time
+	Until    time.Time
+	Limit    int // Maximum number of results, 0 for no limit
+}
+
+// archiveEntry is the in-memory index of an archived product. The text is
+// only read from disk when a product is returned by a query.
+type archiveEntry struct {
+	product ArchivedProduct // Text is always empty
+	file    string
+	offset  int64
+	length  int
+}
+
+// archiveIndexRecord is a line of a day's index file, the metadata of an
+// archived product and where to find it in the day's product file
+type archiveIndexRecord struct {
+	ArchivedProduct
+	Offset int64
+	Length int
+}
+
+// ProductArchive is an on-disk archive of every received product. Products
+// are appended as JSON lines to one file per UTC day, and their metadata to a
+// matching index file. The indexes are loaded into memory on startup, sorted
+// by received time with lookups by ID and station, so only the text of
+// products returned by a query is read from disk.
+type ProductArchive struct {
+	mu        sync.RWMutex
+	dir       string
+	retention time.Duration
+	entries   []*archiveEntry            // sorted by received time
+	byID      map[string]*archiveEntry   // NWWS ID -> latest entry
+	byStation map[string][]*archiveEntry // station -> entries sorted by received time
+	current   *os.File                   // product file for the current day
+	index     *os.File                   // index file for the current day
+	currentAt string                     // day of the current files
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// OpenProductArchive opens or creates an archive in dir, indexing any existing products
+func OpenProductArchive(dir string, retention time.Duration) (*ProductArchive, error) {
+	if retention <= 0 {
+		retention = DefaultArchiveRetention
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	archive := &ProductArchive{
+		dir:       dir,
+		retention: retention,
+		byID:      make(map[string]*archiveEntry),
+		byStation: make(map[string][]*archiveEntry),
+		stop:      make(chan struct{}),
+	}
+
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+
+	archive.pruneFiles(time.Now())
+	if err := archive.loadIndex(); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("dir", dir).
+		Dur("retention", retention).
+		Int("products", len(archive.entries)).
+		Msg("Opened product archive")
+
+	go archive.pruneLoop()
+	return archive, nil
+}
+
+// loadIndex loads the index of every archive file. Must hold pa.mu.
+func (pa *ProductArchive) loadIndex() error {
+	files, err := filepath.Glob(filepath.Join(pa.dir, "*"+archiveFileExt))
+	if err != nil {
+		return fmt.Errorf("failed to list archive files: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if err := pa.indexFile(file); err != nil {
+			log.Error().Err(err).Str("file", file).Msg("Failed to index archive file")
+		}
+	}
+	return nil
+}
+
+// indexFile loads a day's index file. Products missing from it, because the
+// index was written by an older version or a write was interrupted, are read
+// from the product file and added to the index. Must hold pa.mu.
+func (pa *ProductArchive) indexFile(file string) error {
+	indexed, err := pa.readIndexFile(file)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if indexed < info.Size() {
+		if err := pa.reindexFile(file, indexed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readIndexFile adds the records in a day's index file to the index,
+// returning how much of the product file they cover. Must hold pa.mu.
+func (pa *ProductArchive) readIndexFile(file string) (int64, error) {
+	f, err := os.Open(indexPath(file))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var indexed int64
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCaptureLineLen)
+	for scanner.Scan() {
+		var record archiveIndexRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Warn().Err(err).Str("file", indexPath(file)).Msg("Skipping corrupt archive index entry")
+			continue
+		}
+		if record.Offset != indexed {
+			// Records are written in file order, so a gap means the index
+			// is out of step with the product file and must be rebuilt
+			return pa.dropFile(file), nil
+		}
+		pa.insert(&archiveEntry{product: record.ArchivedProduct, file: file, offset: record.Offset, length: record.Length})
+		indexed = record.Offset + int64(record.Length)
+	}
+	return indexed, scanner.Err()
+}
+
+// dropFile removes a file's products from the index, returning 0 as the
+// amount of the file now indexed. Must hold pa.mu.
+func (pa *ProductArchive) dropFile(file string) int64 {
+	pa.removeFiles(map[string]bool{file: true})
+	_ = os.Remove(indexPath(file))
+	return 0
+}
+
+// reindexFile reads the products in a product file from offset on, adding
+// them to the in-memory and on-disk indexes. Must hold pa.mu.
+func (pa *ProductArchive) reindexFile(file string, offset int64) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	index, err := os.OpenFile(indexPath(file), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive index: %w", err)
+	}
+	defer index.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var product ArchivedProduct
+			if jsonErr := json.Unmarshal(line, &product); jsonErr != nil {
+				log.Warn().Err(jsonErr).Str("file", file).Int64("offset", offset).Msg("Skipping corrupt archive entry")
+			} else {
+				product.Text = ""
+				entry := &archiveEntry{product: product, file: file, offset: offset, length: len(line)}
+				if err := writeIndexRecord(index, entry); err != nil {
+					return err
+				}
+				pa.insert(entry)
+			}
+		}
+		offset += int64(len(line))
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// indexPath returns the index file for a day's product file
+func indexPath(file string) string {
+	return strings.TrimSuffix(file, archiveFileExt) + archiveIndexExt
+}
+
+// writeIndexRecord appends an entry to a day's index file
+func writeIndexRecord(index *os.File, entry *archiveEntry) error {
+	data, err := json.Marshal(archiveIndexRecord{ArchivedProduct: entry.product, Offset: entry.offset, Length: entry.length})
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive index entry: %w", err)
+	}
+	if _, err := index.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write archive index: %w", err)
+	}
+	return nil
+}
+
+// insert adds an entry to the in-memory index, keeping it sorted by received
+// time as products can arrive out of order. Must hold pa.mu.
+func (pa *ProductArchive) insert(entry *archiveEntry) {
+	pa.entries = insertEntry(pa.entries, entry)
+	station := strings.ToUpper(entry.product.Station)
+	pa.byStation[station] = insertEntry(pa.byStation[station], entry)
+	if existing, found := pa.byID[entry.product.ID]; !found || !entry.product.Received.Before(existing.product.Received) {
+		pa.byID[entry.product.ID] = entry
+	}
+}
+
+// insertEntry inserts an entry after any received at the same time or earlier
+func insertEntry(entries []*archiveEntry, entry *archiveEntry) []*archiveEntry {
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].product.Received.After(entry.product.Received)
+	})
+	entries = append(entries, nil)
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	return entries
+}
+
+// Add appends a product to the archive
+func (pa *ProductArchive) Add(product ArchivedProduct) error {
+	data, err := json.Marshal(product)
+	if err != nil {
+		return fmt.Errorf("failed to marshal product: %w", err)
+	}
+	data = append(data, '\n')
+
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	f, index, err := pa.filesFor(product.Received)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat archive file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	product.Text = ""
+	entry := &archiveEntry{
+		product: product,
+		file:    f.Name(),
+		offset:  info.Size(),
+		length:  len(data),
+	}
+	pa.insert(entry)
+
+	// A missing index record is recovered from the product file on startup
+	return writeIndexRecord(index, entry)
+}
+
+// filesFor returns the open product and index files for the day of t. The
+// current day's files are kept open, and products received late for an
+// earlier day open that day's files instead. Must hold pa.mu.
+func (pa *ProductArchive) filesFor(t time.Time) (*os.File, *os.File, error) {
+	day := t.UTC().Format(archiveFileLayout)
+	if pa.current != nil && pa.currentAt == day {
+		return pa.current, pa.index, nil
+	}
+
+	pa.closeFiles()
+
+	path := filepath.Join(pa.dir, day+archiveFileExt)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open archive file: %w", err)
+	}
+	index, err := os.OpenFile(indexPath(path), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("failed to open archive index: %w", err)
+	}
+	pa.current = f
+	pa.index = index
+	pa.currentAt = day
+	return f, index, nil
+}
+
+// closeFiles closes the current day's files. Must hold pa.mu.
+func (pa *ProductArchive) closeFiles() error {
+	if pa.current == nil {
+		return nil
+	}
+	err := pa.current.Close()
+	if indexErr := pa.index.Close(); err == nil {
+		err = indexErr
+	}
+	pa.current, pa.index, pa.currentAt = nil, nil, ""
+	return err
+}
+
+// Query returns matching products, newest first. Station queries and time
+// ranges only scan the products they could match.
+func (pa *ProductArchive) Query(q ArchiveQuery) ([]ArchivedProduct, error) {
+	pa.mu.RLock()
+	defer pa.mu.RUnlock()
+
+	candidates := pa.entries
+	if q.Station != "" {
+		candidates = pa.byStation[strings.ToUpper(q.Station)]
+	}
+	first := 0
+	if !q.Since.IsZero() {
+		first = sort.Search(len(candidates), func(i int) bool {
+			return !candidates[i].product.Received.Before(q.Since)
+		})
+	}
+	last := len(candidates)
+	if !q.Until.IsZero() {
+		last = sort.Search(len(candidates), func(i int) bool {
+			return candidates[i].product.Received.After(q.Until)
+		})
+	}
+
+	var matched []*archiveEntry
+	for i := last - 1; i >= first; i-- {
+		entry := candidates[i]
+		if !q.matches(&entry.product) {
+			continue
+		}
+		matched = append(matched, entry)
+		if q.Limit > 0 && len(matched) >= q.Limit {
+			break
+		}
+	}
+
+	result := make([]ArchivedProduct, 0, len(matched))
+	for _, entry := range matched {
+		product, err := pa.read(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, product)
+	}
+	return result, nil
+}
+
+// Get returns a single product by its NWWS ID
+func (pa *ProductArchive) Get(id string) (*ArchivedProduct, error) {
+	pa.mu.RLock()
+	defer pa.mu.RUnlock()
+
+	entry, found := pa.byID[id]
+	if !found {
+		return nil, nil
+	}
+	product, err := pa.read(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Count returns the number of archived products
+func (pa *ProductArchive) Count() int {
+	pa.mu.RLock()
+	defer pa.mu.RUnlock()
+
+	return len(pa.entries)
+}
+
+// read loads the full product for an index entry from disk
+func (pa *ProductArchive) read(entry *archiveEntry) (ArchivedProduct, error) {
+	f, err := os.Open(entry.file)
+	if err != nil {
+		return ArchivedProduct{}, fmt.Errorf("failed to open archive file: %w", err)
+	}
+	defer f.Close()
+
+	data := make([]byte, entry.length)
+	if _, err := f.ReadAt(data, entry.offset); err != nil {
+		return ArchivedProduct{}, fmt.Errorf("failed to read archive file: %w", err)
+	}
+
+	var product ArchivedProduct
+	if err := json.Unmarshal(data, &product); err != nil {
+		return ArchivedProduct{}, fmt.Errorf("failed to parse archived product: %w", err)
+	}
+	return product, nil
+}
+
+func (q *ArchiveQuery) matches(p *ArchivedProduct) bool {
+	if q.Station != "" && !strings.EqualFold(q.Station, p.Station) {
+		return false
+	}
+	if q.AwipsID != "" {
+		awipsID := strings.ToUpper(q.AwipsID)
+		if awipsID != p.AwipsID && !(len(awipsID) == 3 && strings.HasPrefix(p.AwipsID, awipsID)) {
+			return false
+		}
+	}
+	if q.Category != "" && !strings.EqualFold(q.Category, p.Category) {
+		return false
+	}
+	if q.EventKey != "" && !containsString(p.Events, q.EventKey) {
+		return false
+	}
+	if !q.Since.IsZero() && p.Received.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && p.Received.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (pa *ProductArchive) pruneLoop() {
+	ticker := time.NewTicker(ArchivePruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pa.stop:
+			return
+		case now := <-ticker.C:
+			pa.Prune(now)
+		}
+	}
+}
+
+// Prune removes products older than the retention period
+func (pa *ProductArchive) Prune(now time.Time) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	removed := pa.pruneFiles(now)
+	if len(removed) == 0 {
+		return
+	}
+	pa.removeFiles(removed)
+
+	log.Info().Int("files", len(removed)).Msg("Pruned product archive")
+}
+
+// removeFiles drops the products in the given files from the in-memory
+// index. Must hold pa.mu.
+func (pa *ProductArchive) removeFiles(files map[string]bool) {
+	keep := func(entries []*archiveEntry) []*archiveEntry {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if !files[entry.file] {
+				kept = append(kept, entry)
+			}
+		}
+		return kept
+	}
+
+	pa.entries = keep(pa.entries)
+	for station, entries := range pa.byStation {
+		if entries = keep(entries); len(entries) > 0 {
+			pa.byStation[station] = entries
+		} else {
+			delete(pa.byStation, station)
+		}
+	}
+	for id, entry := range pa.byID {
+		if files[entry.file] {
+			delete(pa.byID, id)
+		}
+	}
+}
+
+// pruneFiles deletes archive files for days entirely outside the retention
+// period, returning the removed paths. Must hold pa.mu.
+func (pa *ProductArchive) pruneFiles(now time.Time) map[string]bool {
+	files, err := filepath.Glob(filepath.Join(pa.dir, "*"+archiveFileExt))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list archive files")
+		return nil
+	}
+
+	cutoff := now.UTC().Add(-pa.retention)
+	removed := make(map[string]bool)
+	for _, file := range files {
+		day, err := time.Parse(archiveFileLayout, strings.TrimSuffix(filepath.Base(file), archiveFileExt))
+		if err != nil {
+			continue
+		}
+		if !day.Add(24 * time.Hour).Before(cutoff) {
+			continue
+		}
+		if pa.current != nil && pa.current.Name() == file {
+			_ = pa.closeFiles()
+		}
+		if err := os.Remove(file); err != nil {
+			log.Error().Err(err).Str("file", file).Msg("Failed to remove archive file")
+			continue
+		}
+		if err := os.Remove(indexPath(file)); err != nil && !os.IsNotExist(err) {
+			log.Error().Err(err).Str("file", indexPath(file)).Msg("Failed to remove archive index")
+		}
+		removed[file] = true
+	}
+	return removed
+}
+
+// Close stops the prune goroutine and closes the current archive file. It is
+// safe to call more than once.
+func (pa *ProductArchive) Close() error {
+	pa.stopOnce.Do(func() {
+		close(pa.stop)
+	})
+
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	return pa.closeFiles()
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func archivedProduct(id, station string, received time.Time) ArchivedProduct {
+	return ArchivedProduct{
+		ID:       id,
+		Received: received,
+		Station:  station,
+		AwipsID:  "AFD" + strings.TrimPrefix(station, "K"),
+		Category: "Forecast",
+		Text:     "text of " + id,
+	}
+}
+
+func archiveIDs(products []ArchivedProduct) string {
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestProductArchive(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	archive, err := OpenProductArchive(dir, 2*24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+
+	// 1.3 arrives after 1.4 but was received earlier, across a day boundary
+	for _, product := range []ArchivedProduct{
+		archivedProduct("1.1", "KDTX", yesterday),
+		archivedProduct("1.2", "KOUN", yesterday.Add(time.Minute)),
+		archivedProduct("1.4", "KDTX", now.Add(time.Minute)),
+		archivedProduct("1.3", "KDTX", now),
+	} {
+		if err := archive.Add(product); err != nil {
+			t.Fatalf("failed to add %s: %v", product.ID, err)
+		}
+	}
+
+	check := func(archive *ProductArchive) {
+		t.Helper()
+
+		product, err := archive.Get("1.2")
+		if err != nil || product == nil {
+			t.Fatalf("failed to get 1.2: %v", err)
+		}
+		if product.Text != "text of 1.2" || product.Station != "KOUN" {
+			t.Errorf("got %+v", product)
+		}
+		if product, err := archive.Get("9.9"); product != nil || err != nil {
+			t.Errorf("got %v, %v for an unknown ID", product, err)
+		}
+
+		for _, test := range []struct {
+			query ArchiveQuery
+			want  string
+		}{
+			{ArchiveQuery{}, "1.4,1.3,1.2,1.1"},
+			{ArchiveQuery{Station: "kdtx"}, "1.4,1.3,1.1"},
+			{ArchiveQuery{Station: "KDTX", Limit: 2}, "1.4,1.3"},
+			{ArchiveQuery{AwipsID: "AFD"}, "1.4,1.3,1.2,1.1"},
+			{ArchiveQuery{AwipsID: "AFDOUN"}, "1.2"},
+			{ArchiveQuery{Since: now}, "1.4,1.3"},
+			{ArchiveQuery{Until: now}, "1.3,1.2,1.1"},
+			{ArchiveQuery{Station: "KDTX", Since: yesterday.Add(time.Second), Until: now}, "1.3"},
+			{ArchiveQuery{Station: "KJAX"}, ""},
+		} {
+			products, err := archive.Query(test.query)
+			if err != nil {
+				t.Fatalf("query %+v failed: %v", test.query, err)
+			}
+			if got := archiveIDs(products); got != test.want {
+				t.Errorf("query %+v: got %s, want %s", test.query, got, test.want)
+			}
+		}
+	}
+	check(archive)
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+
+	// Reopening loads the index files
+	archive, err = OpenProductArchive(dir, 2*24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to reopen archive: %v", err)
+	}
+	check(archive)
+
+	// Yesterday's file is removed once the whole day is outside the retention
+	archive.Prune(now.Add(2*24*time.Hour + time.Hour))
+	if products, _ := archive.Query(ArchiveQuery{}); archiveIDs(products) != "1.4,1.3" {
+		t.Errorf("after pruning got %s", archiveIDs(products))
+	}
+	if product, _ := archive.Get("1.1"); product != nil {
+		t.Error("pruned product is still returned")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 2 {
+		t.Errorf("archive has files %v, want one day's product and index files", files)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+}
+
+func TestProductArchiveRebuildsMissingIndex(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+
+	archive, err := OpenProductArchive(dir, 0)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	for _, id := range []string{"2.1", "2.2"} {
+		if err := archive.Add(archivedProduct(id, "KDTX", now)); err != nil {
+			t.Fatalf("failed to add %s: %v", id, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+
+	// Archives written before index files existed only have product files
+	indexes, _ := filepath.Glob(filepath.Join(dir, "*"+archiveIndexExt))
+	for _, index := range indexes {
+		if err := os.Remove(index); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive, err = OpenProductArchive(dir, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen archive: %v", err)
+	}
+	defer archive.Close()
+
+	if product, err := archive.Get("2.2"); err != nil || product == nil || product.Text != "text of 2.2" {
+		t.Errorf("got %v, %v", product, err)
+	}
+	if indexes, _ := filepath.Glob(filepath.Join(dir, "*"+archiveIndexExt)); len(indexes) != 1 {
+		t.Errorf("index was not rewritten, found %v", indexes)
+	}
+}
+
+func TestProductArchiveCloseTwice(t *testing.T) {
+	archive, err := OpenProductArchive(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	if err := archive.Add(archivedProduct("3.1", "KDTX", time.Now().UTC())); err != nil {
+		t.Fatalf("failed to add product: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// Largest capture line accepted on replay, products are well under this
+const maxCaptureLineLen = 16 * 1024 * 1024
+
+var errReplayStopped = errors.New("replay stopped")
+
+// CapturedMessage is a received NWWS-OI product as written to a capture file,
+// one JSON object per line
+type CapturedMessage struct {
+	Received time.Time
+	Site     string `json:",omitempty"`
+	ID       string
+	Cccc     string
+	Ttaaii   string
+	Issue    string
+	AwipsID  string
+	Text     string
+}
+
+// Extension returns the captured product as the stanza extension it was received as
+func (m *CapturedMessage) Extension() *nwwsio.NWWSOIMessageXExtension {
+	return &nwwsio.NWWSOIMessageXExtension{
+		ID:      m.ID,
+		Cccc:    m.Cccc,
+		Ttaaii:  m.Ttaaii,
+		Issue:   m.Issue,
+		AwipsID: m.AwipsID,
+		Text:    m.Text,
+	}
+}
+
+// CaptureWriter appends every received product to a newline-delimited JSON file
+type CaptureWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// OpenCaptureWriter opens a capture file for appending, creating it if needed
+func OpenCaptureWriter(path string) (*CaptureWriter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create capture directory: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %w", err)
+	}
+
+	log.Info().Str("file", path).Msg("Capturing received products")
+	return &CaptureWriter{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Write appends a received product to the capture file
+func (cw *CaptureWriter) Write(site string, messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, received time.Time) error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	return cw.enc.Encode(CapturedMessage{
+		Received: received.UTC(),
+		Site:     site,
+		ID:       messageNWWSIOX.ID,
+		Cccc:     messageNWWSIOX.Cccc,
+		Ttaaii:   messageNWWSIOX.Ttaaii,
+		Issue:    messageNWWSIOX.Issue,
+		AwipsID:  messageNWWSIOX.AwipsID,
+		Text:     messageNWWSIOX.Text,
+	})
+}
+
+// Close closes the capture file
+func (cw *CaptureWriter) Close() error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	return cw.file.Close()
+}
+
+// ReadCapture calls fn for every message in a capture file, in order. Blank
+// lines are skipped.
+func ReadCapture(r io.Reader, fn func(CapturedMessage) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCaptureLineLen)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var msg CapturedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("invalid capture line %d: %w", line, err)
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// CaptureSource is a ProductSource which replays a capture file. A speed of
+// 1 keeps the original pacing, 10 replays ten times faster and 0 replays as
+// fast as possible.
+type CaptureSource struct {
+	r       io.Reader
+	speed   float64
+	handler ProductHandler
+
+	count    atomic.Int64
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewCaptureSource(r io.Reader, speed float64, handler ProductHandler) *CaptureSource {
+	return &CaptureSource{
+		r:       r,
+		speed:   speed,
+		handler: handler,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Run replays the capture until it is exhausted or the source is stopped
+func (cs *CaptureSource) Run() error {
+	var previous time.Time
+
+	return ReadCapture(cs.r, func(captured CapturedMessage) error {
+		if cs.speed > 0 && !previous.IsZero() && captured.Received.After(previous) {
+			delay := time.Duration(float64(captured.Received.Sub(previous)) / cs.speed)
+			timer := time.NewTimer(delay)
+			select {
+			case <-cs.stop:
+				timer.Stop()
+				return errReplayStopped
+			case <-timer.C:
+			}
+		}
+		previous = captured.Received
+
+		select {
+		case <-cs.stop:
+			return errReplayStopped
+		default:
+		}
+
+		site := captured.Site
+		if site == "" {
+			site = "replay"
+		}
+		// Keep the original receive time so archive timestamps, the dual
+		// site dedup window and gap detection behave as they did live
+		received := captured.Received
+		if received.IsZero() {
+			received = time.Now()
+		}
+		cs.handler.HandleProduct(site, captured.Extension(), received)
+		cs.count.Add(1)
+		return nil
+	})
+}
+
+// Stop ends a replay in progress
+func (cs *CaptureSource) Stop() {
+	cs.stopOnce.Do(func() {
+		close(cs.stop)
+	})
+}
+
+// Count returns the number of products replayed so far
+func (cs *CaptureSource) Count() int {
+	return int(cs.count.Load())
+}
+
+// Replay feeds a capture file through the same pipeline as the live stream,
+// returning the number of products replayed
+func (c *SeabirdClient) Replay(ctx context.Context, r io.Reader, speed float64) (int, error) {
+	source := NewCaptureSource(r, speed, c.pipeline)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			source.Stop()
+		case <-done:
+		}
+	}()
+
+	err := source.Run()
+	if errors.Is(err, errReplayStopped) {
+		err = ctx.Err()
+	}
+	return source.Count(), err
+}
@@ -7,13 +7,12 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/seabird-chat/seabird-go"
 	"github.com/seabird-chat/seabird-go/pb"
-	nwwsio "github.com/seabird-chat/seabird-nwwsio-plugin/internal"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -34,12 +33,15 @@ const (
 	MaxCAPDescriptionLen = 800
 	MaxCAPInstructionLen = 200
 	MaxRegularProductLen = 1000
-	MUCReconnectDelay    = 5 * time.Second
+	ShowPageLen          = 1500
 	ConnectionTimeout    = 3 * time.Second
 )
 
 var Version = "v0.0.0-dev"
 
+// How long to wait before retrying a MUC join, a variable so tests can shorten it
+var MUCReconnectDelay = 5 * time.Second
+
 // generateInstanceID creates a short unique identifier for this instance
 func generateInstanceID() string {
 	b := make([]byte, 4)
@@ -53,47 +55,80 @@ func generateInstanceID() string {
 // SeabirdClient is a basic client for seabird
 type SeabirdClient struct {
 	*seabird.Client
-	NWWSClient     *xmpp.StreamManager
-	nwwsXMPPClient *xmpp.Client
-	mucJID         *stanza.Jid
-	subscriptions  *SubscriptionManager
-
-	// Sequence tracking for detecting missed messages
-	sequenceMu   sync.Mutex
-	lastSequence map[string]int // maps processID -> last sequence number
+	sites         []*ConnectionSupervisor // NWWS-OI connections, one per site in dual-site mode
+	dedup         *Deduplicator           // nil unless connected to more than one site
+	subscriptions *SubscriptionManager
+	events        *EventTracker
+	pipeline      *Pipeline       // takes received products through to delivery
+	archive       *ProductArchive // nil when archiving is disabled
+	admins        map[string]bool // user IDs allowed to run admin commands
+	gaps          *GapTracker     // sequence tracking for detecting missed messages
+	watchdog      *Watchdog       // detects sites that have gone silent
+	capture       *CaptureWriter  // nil unless capture mode is enabled
+	adminChannel  string          // channel for operational alerts, optional
+	metricsAddr   string
 
 	// Context for graceful shutdown
 	ctx        context.Context
 	cancelFunc context.CancelFunc
 }
 
-// NewSeabirdClient returns a new seabird client
-func NewSeabirdClient(seabirdCoreURL, seabirdCoreToken, nwwsioUsername, nwwsioPassword, subscriptionFile string) (*SeabirdClient, error) {
-	log.Info().Str("url", seabirdCoreURL).Msg("Connecting to seabird-core")
-	seabirdClient, err := seabird.NewClient(seabirdCoreURL, seabirdCoreToken)
-	if err != nil {
-		return nil, err
+// Config holds the settings needed to create a SeabirdClient
+type Config struct {
+	SeabirdCoreURL   string
+	SeabirdCoreToken string
+	NWWSIOUsername   string
+	NWWSIOPassword   string
+	SubscriptionFile string        // Optional, subscriptions are not persisted if empty
+	EventFile        string        // Optional, active VTEC events are not persisted if empty
+	AdminUsers       []string      // User IDs allowed to run admin commands and manage channel subscriptions
+	ArchiveDir       string        // Optional, received products are not archived if empty
+	ArchiveRetention time.Duration // How long archived products are kept, DefaultArchiveRetention if zero
+	MetricsAddr      string        // Optional, address to serve metrics on
+	DualSite         bool          // Stay connected to both NWWS-OI sites and deduplicate products
+	NWWSIOServers    []string      // NWWS-OI servers as host:port, DefaultNWWSServers if empty
+	StallThreshold   time.Duration // Silence before a connection is considered stalled, DefaultStallThreshold if zero
+	AdminChannel     string        // Optional, channel to alert about stream outages
+	CaptureFile      string        // Optional, every received product is appended here for replay
+}
+
+// NewSeabirdClient returns a new seabird client. Without a seabird-core URL
+// outgoing messages are logged rather than sent, which is used for replays.
+func NewSeabirdClient(config Config) (*SeabirdClient, error) {
+	var seabirdClient *seabird.Client
+	if config.SeabirdCoreURL != "" {
+		log.Info().Str("url", config.SeabirdCoreURL).Msg("Connecting to seabird-core")
+		var err error
+		seabirdClient, err = seabird.NewClient(config.SeabirdCoreURL, config.SeabirdCoreToken)
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("url", config.SeabirdCoreURL).Msg("Successfully connected to seabird-core")
 	}
-	log.Info().Str("url", seabirdCoreURL).Msg("Successfully connected to seabird-core")
 
 	instanceID := generateInstanceID()
-	mucJID := &stanza.Jid{
-		Node:     "nwws",
-		Domain:   "conference.nwws-oi.weather.gov",
-		Resource: fmt.Sprintf("%s-%s", nwwsioUsername, instanceID),
-	}
 	log.Info().Str("instance_id", instanceID).Msg("Generated unique instance ID")
 
 	client := &SeabirdClient{
 		Client:        seabirdClient,
-		mucJID:        mucJID,
 		subscriptions: NewSubscriptionManager(),
-		lastSequence:  make(map[string]int),
+		events:        NewEventTracker(),
+		admins:        make(map[string]bool),
+		gaps:          NewGapTracker(),
+		watchdog:      NewWatchdog(config.StallThreshold),
+		adminChannel:  config.AdminChannel,
+		metricsAddr:   config.MetricsAddr,
+	}
+
+	client.pipeline = NewPipeline(client.subscriptions, client.events, client)
+
+	for _, admin := range config.AdminUsers {
+		client.admins[admin] = true
 	}
 
 	// Set up persistence if configured
-	if subscriptionFile != "" {
-		client.subscriptions.SetPersistenceFile(subscriptionFile)
+	if config.SubscriptionFile != "" {
+		client.subscriptions.SetPersistenceFile(config.SubscriptionFile)
 		if err := client.subscriptions.Load(); err != nil {
 			log.Error().Err(err).Msg("Failed to load subscriptions from file")
 		}
@@ -101,15 +136,46 @@ func NewSeabirdClient(seabirdCoreURL, seabirdCoreToken, nwwsioUsername, nwwsioPa
 		log.Warn().Msg("No subscription file configured - subscriptions will not persist across restarts")
 	}
 
-	log.Info().Str("username", nwwsioUsername).Msg("Connecting to NWWS-IO")
-	nwwsioClient, nwwsXMPPClient, err := NewNWWSIOClient(nwwsioUsername, nwwsioPassword, instanceID, mucJID, client)
-	if err != nil {
-		return nil, err
+	if config.EventFile != "" {
+		client.events.SetPersistenceFile(config.EventFile)
+		if err := client.events.Load(); err != nil {
+			log.Error().Err(err).Msg("Failed to load active events from file")
+		}
+	}
+	client.events.Start()
+
+	if config.ArchiveDir != "" {
+		archive, err := OpenProductArchive(config.ArchiveDir, config.ArchiveRetention)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open product archive: %w", err)
+		}
+		client.archive = archive
+		client.pipeline.SetArchive(archive)
+	} else {
+		log.Warn().Msg("No archive directory configured - received products will not be archived")
 	}
-	log.Info().Str("username", nwwsioUsername).Msg("Successfully connected to NWWS-IO")
 
-	client.NWWSClient = nwwsioClient
-	client.nwwsXMPPClient = nwwsXMPPClient
+	if config.CaptureFile != "" {
+		capture, err := OpenCaptureWriter(config.CaptureFile)
+		if err != nil {
+			return nil, err
+		}
+		client.capture = capture
+		client.pipeline.AddIntake(client.captureProduct)
+	}
+	client.pipeline.AddIntake(client.trackProduct)
+
+	// Connections are made by the supervisors once the client is running
+	servers := config.NWWSIOServers
+	if len(servers) == 0 {
+		servers = DefaultNWWSServers
+	}
+	if config.DualSite {
+		client.sites = NewDualSiteNWWSIOClients(config.NWWSIOUsername, config.NWWSIOPassword, instanceID, servers, client.pipeline)
+		client.dedup = NewDeduplicator(DedupWindow)
+	} else {
+		client.sites = []*ConnectionSupervisor{NewNWWSIOClient(config.NWWSIOUsername, config.NWWSIOPassword, instanceID, servers, client.pipeline)}
+	}
 
 	return client, nil
 }
@@ -129,117 +195,39 @@ func (c *SeabirdClient) Shutdown() error {
 		}
 	}
 
-	if c.nwwsXMPPClient != nil && c.mucJID != nil {
-		err := c.nwwsXMPPClient.Send(stanza.Presence{
-			Attrs: stanza.Attrs{
-				To:   c.mucJID.Full(),
-				Type: stanza.PresenceTypeUnavailable,
-			},
-		})
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to send presence unavailable")
+	if c.events != nil {
+		if err := c.events.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to save active events during shutdown")
 		}
 	}
 
-	if c.NWWSClient != nil {
-		c.NWWSClient.Stop()
-	}
-
-	if c.Client != nil {
-		return c.Client.Close()
-	}
-	return nil
-}
-
-// getAvailableNWWSIOSite attempts to connect to college park & boulder NWWS-IO
-// sites and will return an XMPP client for the first successful site.
-func getAvailableNWWSIOSite(nwwsioUsername, nwwsioPassword, instanceID string) (onlineNWWSIOConfig *xmpp.Config, err error) {
-	router := xmpp.NewRouter()
-	config := xmpp.Config{
-		Jid:            fmt.Sprintf("%s@%s/%s-%s", nwwsioUsername, NWWSDomain, NWWSResource, instanceID),
-		Credential:     xmpp.Password(nwwsioPassword),
-		Insecure:       false,
-		ConnectTimeout: int(ConnectionTimeout.Seconds()),
-	}
-
-	collegeParkConfig := xmpp.TransportConfiguration{
-		Address: fmt.Sprintf("%s:%s", NWWSCollegePark, NWWSServerPort),
-		Domain:  NWWSDomain,
-	}
-	config.TransportConfiguration = collegeParkConfig
-
-	client, err := xmpp.NewClient(&config, router, errorHandler)
-	if err != nil {
-		return nil, err
-	}
-	log.Info().Str("site", config.Address).Msg("Testing connection to NWWS-IO site")
-	err = client.Connect()
-	if err != nil {
-		log.Warn().Err(err).Str("failed_site", NWWSCollegePark).Str("trying_site", NWWSBoulder).Msg("Failed to connect to NWWS-IO server, trying backup")
-		_ = client.Disconnect()
-
-		boulderConfig := xmpp.TransportConfiguration{
-			Address: fmt.Sprintf("%s:%s", NWWSBoulder, NWWSServerPort),
-			Domain:  NWWSDomain,
+	if c.capture != nil {
+		if err := c.capture.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close capture file during shutdown")
 		}
-		config.TransportConfiguration = boulderConfig
+	}
 
-		client, err = xmpp.NewClient(&config, router, errorHandler)
-		if err != nil {
-			return nil, err
+	if c.archive != nil {
+		if err := c.archive.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close product archive during shutdown")
 		}
-
-		log.Info().Str("site", config.Address).Msg("Testing connection to NWWS-IO site")
-		err = client.Connect()
-		if err != nil {
-			_ = client.Disconnect()
-			log.Error().Msg("Failed to connect to all NWWS-IO sites")
-			return nil, fmt.Errorf("Failed to connect to all NWWS-IO sites")
-		}
-	}
-	err = client.Disconnect()
-	if err != nil {
-		return nil, err
 	}
-	return &config, nil
-}
 
-// NewNWWSIOClient returns a new NWWS-IO Client
-func NewNWWSIOClient(nwwsioUsername, nwwsioPassword, instanceID string, mucJID *stanza.Jid, client *SeabirdClient) (*xmpp.StreamManager, *xmpp.Client, error) {
-	onlineClientConfig, err := getAvailableNWWSIOSite(nwwsioUsername, nwwsioPassword, instanceID)
-	if err != nil {
-		return nil, nil, err
+	for _, site := range c.sites {
+		site.Stop()
 	}
 
-	router := xmpp.NewRouter()
-	router.HandleFunc("message", func(s xmpp.Sender, p stanza.Packet) {
-		handleMessage(s, p, client)
-	})
-	router.HandleFunc("presence", func(s xmpp.Sender, p stanza.Packet) {
-		handlePresence(s, p, mucJID)
-	})
-	router.NewRoute().IQNamespaces("jabber:iq:version").HandlerFunc(handleVersion)
-
-	onlineClient, err := xmpp.NewClient(onlineClientConfig, router, func(err error) {
-		mucErrorHandler(err, mucJID)
-	})
-	if err != nil {
-		return nil, nil, err
+	if c.Client != nil {
+		return c.Client.Close()
 	}
-
-	cm := xmpp.NewStreamManager(onlineClient, nwwsioPostConnect(mucJID))
-	return cm, onlineClient, nil
+	return nil
 }
 
-func nwwsioPostConnect(mucJID *stanza.Jid) func(xmpp.Sender) {
-	return func(c xmpp.Sender) {
-		log.Info().Msg("NWWS-IO connection established")
-		err := joinMUC(c, mucJID)
-		if err != nil {
-			log.Fatal().Err(err).Msg("Failed to join Multi-user Chat")
-		}
-		log.Info().Str("jid", mucJID.Full()).Msg("Successfully joined Multi-user Chat - ready to receive messages")
-	}
+// NewNWWSIOClient returns a supervised NWWS-IO connection which fails over between servers
+func NewNWWSIOClient(nwwsioUsername, nwwsioPassword, instanceID string, servers []string, handler ProductHandler) *ConnectionSupervisor {
+	config := newXMPPConfig(nwwsioUsername, nwwsioPassword, fmt.Sprintf("%s-%s", NWWSResource, instanceID))
+	mucJID := newMUCJID(fmt.Sprintf("%s-%s", nwwsioUsername, instanceID))
+	return newConnectionSupervisor("nwws", servers, config, mucJID, handler)
 }
 
 func joinMUC(c xmpp.Sender, toJID *stanza.Jid) error {
@@ -252,33 +240,23 @@ func joinMUC(c xmpp.Sender, toJID *stanza.Jid) error {
 	})
 }
 
-// checkSequenceGaps detects and logs any missed messages based on sequence IDs
-func checkSequenceGaps(client *SeabirdClient, processID string, sequenceID int) {
-	client.sequenceMu.Lock()
-	defer client.sequenceMu.Unlock()
-
-	lastSeq, exists := client.lastSequence[processID]
-	if exists {
-		expected := lastSeq + 1
-		if sequenceID != expected {
-			missedCount := sequenceID - expected
-			log.Warn().
-				Str("process_id", processID).
-				Int("expected_seq", expected).
-				Int("received_seq", sequenceID).
-				Int("missed_count", missedCount).
-				Msg("Detected missed messages - sequence gap")
-		}
-	}
-	client.lastSequence[processID] = sequenceID
-}
-
 // productInfo holds parsed product identification information
 type productInfo struct {
 	productID       *nwwsio.WMOProductID
 	productName     string
 	productCategory string
+	awipsID         string // trimmed and uppercased AWIPS ID
 	capAlert        *nwwsio.Alert
+	vtec            []nwwsio.VTEC
+	ugc             []nwwsio.UGCCode
+	polygons        []nwwsio.Polygon
+	same            []nwwsio.SAMECode
+	heading         *nwwsio.WMOHeading       // nil when the text has no WMO heading
+	segments        *nwwsio.SegmentedProduct // nil for CAP products
+	tags            *nwwsio.ThreatTags       // nil when the product has no threat tags
+	escalations     []string                 // PDS, tornado and flash flood emergencies, most severe first
+	eventUpdates    []EventUpdate
+	supersedes      string // ID of the earlier version a correction or amendment replaces
 }
 
 // parseProductInfo extracts product identification from the NWWS message
@@ -288,11 +266,12 @@ func parseProductInfo(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension) (*productI
 		return nil, fmt.Errorf("failed to parse WMO product ID: %w", err)
 	}
 
-	// Default to WMO data type
+	// Default to the WMO data designator description
 	info := &productInfo{
 		productID:       productID,
-		productName:     productID.GetDataType(),
+		productName:     productID.Describe(),
 		productCategory: "Unknown",
+		awipsID:         strings.ToUpper(strings.TrimSpace(messageNWWSIOX.AwipsID)),
 	}
 
 	// Try to get more specific product info from AWIPS ID
@@ -304,9 +283,16 @@ func parseProductInfo(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension) (*productI
 			Str("cccc", messageNWWSIOX.Cccc).
 			Str("ttaaii", messageNWWSIOX.Ttaaii).
 			Msg("Failed to parse AWIPS ID, using WMO type as fallback")
+	} else if product, found := awipsID.GetProductInfo(); found {
+		info.productName = product.Name
+		info.productCategory = product.Category
+	}
+
+	heading, err := messageNWWSIOX.ParseWMOHeading()
+	if err != nil {
+		log.Debug().Err(err).Str("id", messageNWWSIOX.ID).Msg("Failed to parse WMO heading")
 	} else {
-		info.productName = awipsID.GetProductName()
-		info.productCategory = awipsID.GetProductCategory()
+		info.heading = heading
 	}
 
 	// Try to parse CAP message if it looks like one
@@ -319,20 +305,105 @@ func parseProductInfo(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension) (*productI
 		}
 	}
 
+	// Text products may carry different segments for different areas
+	if info.capAlert == nil {
+		info.segments = nwwsio.SplitSegments(messageNWWSIOX.Text, parseIssueTime(messageNWWSIOX.Issue))
+	}
+
+	// Prefer the VTEC parameters from CAP, falling back to the product text
+	if info.capAlert != nil && info.capAlert.GetPrimaryInfo() != nil {
+		info.vtec = info.capAlert.GetPrimaryInfo().GetVTEC()
+	}
+	if len(info.vtec) == 0 {
+		info.vtec = nwwsio.FindVTEC(messageNWWSIOX.Text)
+	}
+
+	// Likewise prefer the CAP area geocodes for UGC codes
+	if info.capAlert != nil && info.capAlert.GetPrimaryInfo() != nil {
+		for _, area := range info.capAlert.GetPrimaryInfo().Area {
+			info.ugc = append(info.ugc, area.GetUGC()...)
+			info.same = append(info.same, area.GetSAME()...)
+		}
+	}
+	if len(info.ugc) == 0 {
+		info.ugc = nwwsio.FindUGCCodes(messageNWWSIOX.Text)
+	}
+
+	// Storm-based warning polygons, again preferring CAP
+	if info.capAlert != nil && info.capAlert.GetPrimaryInfo() != nil {
+		for _, area := range info.capAlert.GetPrimaryInfo().Area {
+			info.polygons = append(info.polygons, area.GetPolygons()...)
+		}
+	}
+	if len(info.polygons) == 0 {
+		info.polygons = nwwsio.FindLatLonPolygons(messageNWWSIOX.Text)
+	}
+
+	// Storm motion and threat tags, again preferring CAP
+	if info.capAlert != nil && info.capAlert.GetPrimaryInfo() != nil {
+		info.tags = info.capAlert.GetPrimaryInfo().GetThreatTags()
+	}
+	if info.tags == nil {
+		info.tags = nwwsio.FindThreatTags(messageNWWSIOX.Text)
+	}
+
+	// PDS and emergency escalations
+	if hasEscalations(info) {
+		text := messageNWWSIOX.Text
+		if info.capAlert != nil && info.capAlert.GetPrimaryInfo() != nil {
+			capInfo := info.capAlert.GetPrimaryInfo()
+			text = capInfo.Headline + "\n" + capInfo.Description
+		}
+		info.escalations = nwwsio.FindEscalations(text, info.tags)
+	}
+
 	return info, nil
 }
 
+// hasEscalations reports whether a product can carry PDS and emergency
+// escalations: watches, warnings, statements, products with VTEC or threat
+// tags and CAP alerts. Discussions and summaries mention past emergencies so
+// they are left out.
+func hasEscalations(info *productInfo) bool {
+	switch info.productCategory {
+	case "Warning", "Watch", "Statement":
+		return true
+	}
+	return len(info.vtec) > 0 || info.tags != nil || info.capAlert != nil
+}
+
 // logProductReceipt logs the received weather product with appropriate detail
 func logProductReceipt(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, info *productInfo) {
 	baseLog := log.Info().
 		Str("cccc", messageNWWSIOX.Cccc).
 		Str("ttaaii", messageNWWSIOX.Ttaaii).
-		Str("wmo_type", info.productID.GetDataType()).
+		Str("wmo_type", info.productID.Describe()).
 		Str("awipsid", messageNWWSIOX.AwipsID).
 		Str("product", info.productName).
 		Str("category", info.productCategory).
 		Str("issue", messageNWWSIOX.Issue)
 
+	if info.heading != nil && info.heading.BBB != "" {
+		baseLog.Str("bbb", info.heading.BBB)
+	}
+	if len(info.vtec) > 0 {
+		baseLog.
+			Str("vtec_event", info.vtec[0].String()).
+			Str("vtec_action", info.vtec[0].Action)
+	}
+	if len(info.ugc) > 0 {
+		baseLog.Int("ugc_count", len(info.ugc))
+	}
+	if len(info.polygons) > 0 {
+		baseLog.Int("polygon_count", len(info.polygons))
+	}
+	if tags := formatThreatTags(info.tags); tags != "" {
+		baseLog.Str("tags", tags)
+	}
+	if len(info.escalations) > 0 {
+		baseLog.Strs("escalations", info.escalations)
+	}
+
 	if info.capAlert != nil {
 		capInfo := info.capAlert.GetPrimaryInfo()
 		if capInfo != nil {
@@ -364,6 +435,11 @@ func buildDisplayName(info *productInfo) string {
 		displayName = fmt.Sprintf("%s (%s)", info.productName, info.productCategory)
 	}
 
+	// Prefer the specific VTEC event over the generic product name
+	if len(info.vtec) > 0 {
+		displayName = info.vtec[0].String()
+	}
+
 	// Enhance with CAP alert details if available
 	if info.capAlert != nil {
 		capInfo := info.capAlert.GetPrimaryInfo()
@@ -377,14 +453,140 @@ func buildDisplayName(info *productInfo) string {
 
 // formatAlertMessage formats the alert message for delivery to subscribers
 func formatAlertMessage(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, info *productInfo) string {
+	// Follow-up products for known events only need a short status update
+	if isEventFollowUp(info) {
+		return formatEscalationBanner(info) + formatRevisionNotice(info) + formatEventFollowUp(messageNWWSIOX, info) + formatProductReference(messageNWWSIOX.ID)
+	}
+
+	var msg string
 	if info.capAlert != nil && info.capAlert.GetPrimaryInfo() != nil {
-		return formatCAPAlert(messageNWWSIOX, info.capAlert)
+		msg = formatCAPAlert(messageNWWSIOX, info.capAlert, info.tags)
+	} else {
+		msg = formatRegularProduct(messageNWWSIOX, info.productName, info.tags)
+	}
+
+	if summary := formatEventSummary(info.eventUpdates); summary != "" {
+		msg = summary + "\n" + msg
+	}
+	return formatEscalationBanner(info) + formatRevisionNotice(info) + msg + formatProductReference(messageNWWSIOX.ID)
+}
+
+// formatEscalationBanner formats the banner which leads PDS and emergency
+// alerts so they stand out from routine warnings
+func formatEscalationBanner(info *productInfo) string {
+	if len(info.escalations) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("!!! %s !!!\n", strings.Join(info.escalations, " / "))
+}
+
+// formatRevisionNotice formats the line marking a correction or amendment of
+// an earlier product, or nothing for an original issuance
+func formatRevisionNotice(info *productInfo) string {
+	if info.heading == nil || !info.heading.Supersedes() {
+		return ""
+	}
+
+	kind := "CORRECTION"
+	if info.heading.IsAmendment() {
+		kind = "AMENDMENT"
+	}
+	if info.supersedes == "" {
+		return fmt.Sprintf("%s (%s)\n", kind, info.heading.BBB)
+	}
+	return fmt.Sprintf("%s (%s) replaces ID %s\n", kind, info.heading.BBB, info.supersedes)
+}
+
+// formatProductReference formats the footer pointing at the full product text
+func formatProductReference(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\nID: %s (full text: !noaa show %s)", id, id)
+}
+
+// isEventFollowUp reports whether every VTEC event in the product was already
+// being tracked, meaning the product is a continuation rather than a new alert
+func isEventFollowUp(info *productInfo) bool {
+	if len(info.eventUpdates) == 0 {
+		return false
+	}
+	for _, update := range info.eventUpdates {
+		if update.Transition == EventNew || update.Previous == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// formatEventSummary formats one status line per VTEC event update
+func formatEventSummary(updates []EventUpdate) string {
+	lines := make([]string, 0, len(updates))
+	for _, update := range updates {
+		lines = append(lines, update.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatEventFollowUp formats a compact status update for a continuation product
+func formatEventFollowUp(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, info *productInfo) string {
+	msg := fmt.Sprintf(
+		"[%s] %s\n"+
+			"%s\n"+
+			"Product: %s | Issued: %s",
+		messageNWWSIOX.Cccc,
+		info.productName,
+		formatEventSummary(info.eventUpdates),
+		messageNWWSIOX.AwipsID,
+		messageNWWSIOX.Issue,
+	)
+	if tags := formatThreatTags(info.tags); tags != "" {
+		msg += "\n" + tags
 	}
-	return formatRegularProduct(messageNWWSIOX, info.productName)
+
+	if info.capAlert != nil {
+		if capInfo := info.capAlert.GetPrimaryInfo(); capInfo != nil && capInfo.Headline != "" {
+			msg += fmt.Sprintf("\n\n%s", capInfo.Headline)
+		}
+	}
+
+	return msg
+}
+
+// formatThreatTags formats storm motion and threat tags on one line, or
+// returns an empty string when there are none
+func formatThreatTags(tags *nwwsio.ThreatTags) string {
+	if tags == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, detection := range []struct{ name, value string }{
+		{"Tornado", tags.Tornado},
+		{"Waterspout", tags.Waterspout},
+		{"Flash flood", tags.FlashFlood},
+	} {
+		if detection.value != "" {
+			parts = append(parts, fmt.Sprintf("%s %s", detection.name, strings.ToLower(detection.value)))
+		}
+	}
+	if tags.MaxHailSize > 0 {
+		parts = append(parts, fmt.Sprintf("Hail %.2f in", tags.MaxHailSize))
+	}
+	if tags.MaxWindGust > 0 {
+		parts = append(parts, fmt.Sprintf("Wind %d mph", tags.MaxWindGust))
+	}
+	if threat := tags.MaxDamageThreat(); threat != "" {
+		parts = append(parts, fmt.Sprintf("Damage threat %s", strings.ToLower(threat)))
+	}
+	if tags.Motion != nil {
+		parts = append(parts, "Storm "+tags.Motion.String())
+	}
+	return strings.Join(parts, " | ")
 }
 
 // formatCAPAlert formats a CAP alert message with full details
-func formatCAPAlert(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, capAlert *nwwsio.Alert) string {
+func formatCAPAlert(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, capAlert *nwwsio.Alert, tags *nwwsio.ThreatTags) string {
 	capInfo := capAlert.GetPrimaryInfo()
 
 	msg := fmt.Sprintf(
@@ -399,6 +601,9 @@ func formatCAPAlert(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, capAlert *nw
 		messageNWWSIOX.AwipsID,
 		messageNWWSIOX.Issue,
 	)
+	if formatted := formatThreatTags(tags); formatted != "" {
+		msg += formatted + "\n"
+	}
 
 	if capInfo.Headline != "" {
 		msg += fmt.Sprintf("\n%s\n", capInfo.Headline)
@@ -422,61 +627,46 @@ func formatCAPAlert(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, capAlert *nw
 }
 
 // formatRegularProduct formats a non-CAP weather product message
-func formatRegularProduct(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, productName string) string {
-	return fmt.Sprintf(
+func formatRegularProduct(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, productName string, tags *nwwsio.ThreatTags) string {
+	header := fmt.Sprintf(
 		"[%s] %s\n"+
-			"Product: %s | Issued: %s\n\n"+
-			"%s",
+			"Product: %s | Issued: %s\n",
 		messageNWWSIOX.Cccc,
 		productName,
 		messageNWWSIOX.AwipsID,
 		messageNWWSIOX.Issue,
-		truncateText(messageNWWSIOX.Text, MaxRegularProductLen),
 	)
+	if formatted := formatThreatTags(tags); formatted != "" {
+		header += formatted + "\n"
+	}
+	return header + "\n" + truncateText(messageNWWSIOX.Text, MaxRegularProductLen)
 }
 
-// shouldSendToSubscriber determines if a subscriber should receive this message based on their filters
-func shouldSendToSubscriber(sub Subscription, productCategory string, isCAP bool) bool {
+// shouldSendToSubscriber determines if a subscriber should receive this
+// message based on their filters. One selector has to choose the product and
+// every constraint has to match it.
+func shouldSendToSubscriber(sub Subscription, info *productInfo) bool {
+	selected, hasSelector := false, false
 	for _, filter := range sub.Filters {
 		filterLower := strings.ToLower(filter)
 
-		if filterLower == "all" {
-			return true
-		}
-		if filterLower == "cap" && isCAP {
-			return true
-		}
-		if filterLower == strings.ToLower(productCategory) {
-			return true
+		if constraint, ok, err := parseConstraint(filterLower); ok {
+			if err != nil || !constraint(info) {
+				return false
+			}
+			continue
 		}
-	}
-	return false
-}
-
-// deliverToSubscribers sends the alert message to all matching subscribers
-func deliverToSubscribers(client *SeabirdClient, messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, info *productInfo, alertMsg string) {
-	subscriptions := client.subscriptions.GetStationSubscriptions(messageNWWSIOX.Cccc)
-	if len(subscriptions) == 0 {
-		return
-	}
 
-	isCAP := info.capAlert != nil
-
-	for _, sub := range subscriptions {
-		if shouldSendToSubscriber(sub, info.productCategory, isCAP) {
-			client.SendPrivateMessage(sub.UserID, alertMsg)
-			log.Info().
-				Str("user_id", sub.UserID).
-				Str("station", messageNWWSIOX.Cccc).
-				Strs("filters", sub.Filters).
-				Str("product_category", info.productCategory).
-				Bool("is_cap", isCAP).
-				Msg("Sent weather alert to subscriber")
+		hasSelector = true
+		if matchesSelector(filterLower, info) {
+			selected = true
 		}
 	}
+	return selected || !hasSelector
 }
 
-func handleMessage(s xmpp.Sender, p stanza.Packet, client *SeabirdClient) {
+// handleMessage passes the NWWS-OI product carried by an XMPP message to the handler
+func handleMessage(s xmpp.Sender, p stanza.Packet, handler ProductHandler, site string) {
 	// Only process Message packets
 	msg, ok := p.(stanza.Message)
 	if !ok {
@@ -493,40 +683,81 @@ func handleMessage(s xmpp.Sender, p stanza.Packet, client *SeabirdClient) {
 	// Normalize AWIPS ID by trimming any whitespace from XML parsing
 	messageNWWSIOX.AwipsID = strings.TrimSpace(messageNWWSIOX.AwipsID)
 
+	handler.HandleProduct(site, &messageNWWSIOX, time.Now())
+}
+
+// captureProduct appends a product to the capture file as it was received
+func (c *SeabirdClient) captureProduct(product *Product) bool {
+	if err := c.capture.Write(product.Site, product.Message, product.Received); err != nil {
+		log.Error().Err(err).Str("id", product.Message.ID).Msg("Failed to capture product")
+	}
+	return true
+}
+
+// trackProduct feeds a product to the metrics, watchdog and sequence gap
+// tracking, dropping it if it was already received from another site
+func (c *SeabirdClient) trackProduct(product *Product) bool {
+	messageNWWSIOX := product.Message
+
+	metricProductsReceived.Add(1)
+	c.observeActivity(product.Site, messageNWWSIOX, product.Received)
+
 	// Check for sequence gaps in the message stream
 	processID, sequenceID, err := messageNWWSIOX.GetSequenceID()
 	if err != nil {
 		log.Debug().Err(err).Str("id", messageNWWSIOX.ID).Msg("Failed to parse sequence ID")
 	} else {
-		checkSequenceGaps(client, processID, sequenceID)
+		checkSequenceGaps(c, product.Site, processID, sequenceID, product.Received)
 	}
 
-	// Parse product identification information
-	info, err := parseProductInfo(&messageNWWSIOX)
-	if err != nil {
-		log.Warn().Err(err).Str("ttaaii", messageNWWSIOX.Ttaaii).Msg("Failed to parse product info")
-		return
+	// In dual-site mode the same product arrives from both sites
+	if c.dedup != nil && c.dedup.Check(product.Site, ProductKey(messageNWWSIOX), product.Received) {
+		metricDuplicateProducts.Add(1)
+		log.Debug().
+			Str("site", product.Site).
+			Str("id", messageNWWSIOX.ID).
+			Str("awipsid", messageNWWSIOX.AwipsID).
+			Msg("Dropping product already received from another site")
+		return false
 	}
+	return true
+}
 
-	// Log receipt of this weather product
-	logProductReceipt(&messageNWWSIOX, info)
-
-	// Build a user-friendly display name for the product
-	displayName := buildDisplayName(info)
-
-	// Store this message in recent history for the station
-	client.subscriptions.AddRecentMessage(RecentMessage{
-		Station:   messageNWWSIOX.Cccc,
-		DataType:  displayName,
-		AwipsID:   messageNWWSIOX.AwipsID,
-		Issue:     messageNWWSIOX.Issue,
-		Text:      messageNWWSIOX.Text,
-		Timestamp: time.Now(),
-	})
+// buildArchivedProduct captures a received product and its parsed metadata for the archive
+func buildArchivedProduct(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, info *productInfo, displayName string, received time.Time) ArchivedProduct {
+	product := ArchivedProduct{
+		ID:          messageNWWSIOX.ID,
+		Received:    received.UTC(),
+		Station:     messageNWWSIOX.Cccc,
+		Ttaaii:      messageNWWSIOX.Ttaaii,
+		AwipsID:     messageNWWSIOX.AwipsID,
+		Issue:       messageNWWSIOX.Issue,
+		Name:        info.productName,
+		Category:    info.productCategory,
+		DisplayName: displayName,
+		IsCAP:       info.capAlert != nil,
+		Text:        messageNWWSIOX.Text,
+	}
+	if info.heading != nil {
+		product.Heading = info.heading.Key()
+		product.BBB = info.heading.BBB
+	}
+	for _, update := range info.eventUpdates {
+		product.Events = append(product.Events, update.Event.Key())
+	}
+	for _, code := range info.ugc {
+		product.UGC = append(product.UGC, code.String())
+	}
+	return product
+}
 
-	// Deliver to any subscribers for this station
-	alertMsg := formatAlertMessage(&messageNWWSIOX, info)
-	deliverToSubscribers(client, &messageNWWSIOX, info, alertMsg)
+// parseIssueTime parses the issue attribute, falling back to the current time
+func parseIssueTime(issue string) time.Time {
+	issued, err := time.Parse(time.RFC3339, strings.TrimSpace(issue))
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return issued
 }
 
 func truncateText(text string, maxLen int) string {
@@ -536,12 +767,38 @@ func truncateText(text string, maxLen int) string {
 	return text[:maxLen] + "...\n[Message truncated]"
 }
 
-func isLikelyCAP(productID *nwwsio.WMOProductID, text string) bool {
-	return productID.T1 == "X" || strings.Contains(text, "<alert")
+// paginateText splits text into pages of at most maxLen bytes, breaking at
+// line boundaries where possible
+func paginateText(text string, maxLen int) []string {
+	var pages []string
+	var page strings.Builder
+
+	for _, line := range strings.SplitAfter(strings.TrimRight(text, "\n"), "\n") {
+		// Lines longer than a page are split wherever they need to be
+		for len(line) > maxLen {
+			if page.Len() > 0 {
+				pages = append(pages, page.String())
+				page.Reset()
+			}
+			pages = append(pages, line[:maxLen])
+			line = line[maxLen:]
+		}
+
+		if page.Len()+len(line) > maxLen {
+			pages = append(pages, page.String())
+			page.Reset()
+		}
+		page.WriteString(line)
+	}
+	if page.Len() > 0 {
+		pages = append(pages, page.String())
+	}
+
+	return pages
 }
 
-func errorHandler(err error) {
-	log.Error().Err(err).Msg("XMPP error")
+func isLikelyCAP(productID *nwwsio.WMOProductID, text string) bool {
+	return productID.T1 == "X" || strings.Contains(text, "<alert")
 }
 
 // mucErrorHandler provides enhanced error handling with MUC recovery
@@ -564,7 +821,7 @@ func mucErrorHandler(err error, mucJID *stanza.Jid) {
 
 // handlePresence handles XMPP presence stanzas, particularly for MUC
 func handlePresence(s xmpp.Sender, p stanza.Packet, mucJID *stanza.Jid) {
-	presence, ok := p.(*stanza.Presence)
+	presence, ok := p.(stanza.Presence)
 	if !ok {
 		return
 	}
@@ -612,6 +869,11 @@ func handleVersion(c xmpp.Sender, p stanza.Packet) {
 }
 
 func (c *SeabirdClient) SendMessage(channelID, text string) {
+	if c.Client == nil {
+		log.Info().Str("channel_id", channelID).Str("text", text).Msg("Not connected to seabird-core, would send message")
+		return
+	}
+
 	ctx := context.Background()
 	_, err := c.Client.Inner.SendMessage(ctx, &pb.SendMessageRequest{
 		ChannelId: channelID,
@@ -625,6 +887,11 @@ func (c *SeabirdClient) SendMessage(channelID, text string) {
 }
 
 func (c *SeabirdClient) SendPrivateMessage(userID, text string) {
+	if c.Client == nil {
+		log.Info().Str("user_id", userID).Str("text", text).Msg("Not connected to seabird-core, would send private message")
+		return
+	}
+
 	ctx := context.Background()
 	_, err := c.Client.Inner.SendPrivateMessage(ctx, &pb.SendPrivateMessageRequest{
 		UserId: userID,
@@ -640,7 +907,7 @@ func (c *SeabirdClient) handleCommandEvents(ctx context.Context) {
 		"noaa": {
 			Name:      "noaa",
 			ShortHelp: "Subscribe to NOAA weather alerts",
-			FullHelp:  "Usage: !noaa <help|subscribe|unsubscribe|list|recent> [options]. Use !noaa help for details.",
+			FullHelp:  "Usage: !noaa <help|subscribe|unsubscribe|list|recent|show> [options]. Use !noaa help for details.",
 		},
 	}
 
@@ -683,188 +950,8 @@ func (c *SeabirdClient) handleCommandEvents(ctx context.Context) {
 	}
 }
 
-func buildFilterConfirmation(stationCode string, filters []string) string {
-	var hasAll, hasCAP bool
-	var categories []string
-
-	for _, f := range filters {
-		switch strings.ToLower(f) {
-		case "all":
-			hasAll = true
-		case "cap":
-			hasCAP = true
-		default:
-			categories = append(categories, f)
-		}
-	}
-
-	if hasAll {
-		return fmt.Sprintf("You'll receive DMs for ALL weather products from %s.", stationCode)
-	}
-	if hasCAP && len(categories) == 0 {
-		return fmt.Sprintf("You'll receive DMs for emergency alerts (CAP) from %s.", stationCode)
-	}
-	if len(categories) > 0 && !hasCAP {
-		return fmt.Sprintf("You'll receive DMs for %s products from %s.", strings.Join(categories, ", "), stationCode)
-	}
-	return fmt.Sprintf("You'll receive DMs for CAP alerts and %s products from %s.", strings.Join(categories, ", "), stationCode)
-}
-
-func (c *SeabirdClient) handleNoaaCommand(event *pb.Event, cmd *pb.CommandEvent) {
-	log.Info().
-		Str("user_id", cmd.Source.User.Id).
-		Str("user_name", cmd.Source.User.DisplayName).
-		Str("channel_id", cmd.Source.ChannelId).
-		Str("command", cmd.Command).
-		Str("arg", cmd.Arg).
-		Msg("Received !noaa command")
-
-	args := strings.Fields(cmd.Arg)
-	if len(args) < 1 {
-		c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa <subscribe|unsubscribe|list> <station|zip> [code]")
-		return
-	}
-
-	action := strings.ToLower(args[0])
-
-	switch action {
-	case "help":
-		helpMsg := "NOAA Weather Alerts: !noaa subscribe station <CODE> [filters...] | unsubscribe station <CODE> | unsubscribe all | list | recent <CODE> | filters | help. Example: !noaa subscribe station KJAX warning"
-		c.SendMessage(cmd.Source.ChannelId, helpMsg)
-
-	case "filters":
-		validFilters := GetValidFilters()
-		msg := "Valid filter options:\n"
-		msg += "Special: all, cap\n"
-		msg += "Categories: " + strings.Join(validFilters[2:], ", ")
-		c.SendMessage(cmd.Source.ChannelId, msg)
-
-	case "subscribe":
-		if len(args) < 3 {
-			c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa subscribe station <code> [filters...]")
-			c.SendMessage(cmd.Source.ChannelId, "Filters: cap (default), all, or any product category")
-			c.SendMessage(cmd.Source.ChannelId, "Use '!noaa filters' to see all valid filter options")
-			return
-		}
-		subType := strings.ToLower(args[1])
-		code := args[2]
-
-		var filters []string
-		if len(args) >= 4 {
-			for _, arg := range args[3:] {
-				for _, part := range strings.Split(arg, ",") {
-					if trimmed := strings.TrimSpace(part); trimmed != "" {
-						filters = append(filters, trimmed)
-					}
-				}
-			}
-		}
-		if len(filters) == 0 {
-			filters = []string{"cap"}
-		}
-
-		// Validate filters before subscribing
-		if invalidFilters := ValidateFilters(filters); len(invalidFilters) > 0 {
-			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid filter(s): %s", strings.Join(invalidFilters, ", ")))
-			c.SendMessage(cmd.Source.ChannelId, "Use '!noaa filters' to see all valid filter options")
-			return
-		}
-
-		if subType == "station" {
-			if err := ValidateStationCode(code); err != nil {
-				c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid station code: %s", err))
-				return
-			}
-
-			c.subscriptions.SubscribeToStation(cmd.Source.User.Id, code, filters)
-			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Subscribed to station %s with filters: %s", strings.ToUpper(code), strings.Join(filters, ", ")))
-
-			confirmMsg := buildFilterConfirmation(strings.ToUpper(code), filters)
-			recent := c.subscriptions.GetRecentMessages(code)
-			if len(recent) > 0 {
-				lastMsg := recent[len(recent)-1]
-				confirmMsg += fmt.Sprintf("\nLast activity: %s (%s ago)",
-					lastMsg.DataType,
-					time.Since(lastMsg.Timestamp).Round(time.Second))
-			}
-			c.SendPrivateMessage(cmd.Source.User.Id, confirmMsg)
-
-		} else {
-			c.SendMessage(cmd.Source.ChannelId, "Invalid subscription type. Use 'station'")
-		}
-
-	case "unsubscribe":
-		if len(args) < 2 {
-			c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa unsubscribe <station|all> [code]")
-			return
-		}
-		subType := strings.ToLower(args[1])
-
-		if subType == "all" {
-			count := c.subscriptions.UnsubscribeFromAll(cmd.Source.User.Id)
-			if count > 0 {
-				c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Removed %d subscription(s)", count))
-			} else {
-				c.SendMessage(cmd.Source.ChannelId, "You have no active subscriptions")
-			}
-			return
-		}
-
-		if len(args) < 3 {
-			c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa unsubscribe station <code>")
-			return
-		}
-		code := args[2]
-
-		if subType == "station" {
-			if c.subscriptions.UnsubscribeFromStation(cmd.Source.User.Id, code) {
-				c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Unsubscribed from station %s", strings.ToUpper(code)))
-			} else {
-				c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Not subscribed to station %s", strings.ToUpper(code)))
-			}
-		} else {
-			c.SendMessage(cmd.Source.ChannelId, "Invalid subscription type. Use 'station' or 'all'")
-		}
-
-	case "recent":
-		if len(args) < 2 {
-			c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa recent <station_code>")
-			return
-		}
-		stationCode := strings.ToUpper(args[1])
-		messages := c.subscriptions.GetRecentMessages(stationCode)
-
-		if len(messages) == 0 {
-			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("No recent messages from %s", stationCode))
-			return
-		}
-
-		var msg strings.Builder
-		msg.WriteString(fmt.Sprintf("Recent messages from %s:\n", stationCode))
-		for i, m := range messages {
-			ago := time.Since(m.Timestamp).Round(time.Second)
-			msg.WriteString(fmt.Sprintf("%d. %s - %s (%s ago)\n", i+1, m.DataType, m.AwipsID, ago))
-		}
-		c.SendMessage(cmd.Source.ChannelId, msg.String())
-
-	case "list":
-		stations := c.subscriptions.GetUserStationSubscriptions(cmd.Source.User.Id)
-
-		msg := "Your subscriptions:\n"
-		if len(stations) > 0 {
-			msg += fmt.Sprintf("Stations: %s\n", strings.Join(stations, ", "))
-		} else {
-			msg = "You have no active subscriptions"
-		}
-
-		c.SendMessage(cmd.Source.ChannelId, msg)
-
-	default:
-		c.SendMessage(cmd.Source.ChannelId, "Unknown action. Use: subscribe, unsubscribe, or list")
-	}
-}
-
-// Run runs both the NWWS client and seabird command handler concurrently
+// Run runs both the NWWS client and seabird command handler concurrently. It
+// returns once any site fails permanently, stopping the others.
 func (c *SeabirdClient) Run() error {
 	// Create a cancellable context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -874,11 +961,39 @@ func (c *SeabirdClient) Run() error {
 
 	g, gctx := errgroup.WithContext(ctx)
 
-	log.Info().Msg("Starting NWWS-IO client")
+	for _, site := range c.sites {
+		log.Info().Str("site", site.name).Strs("servers", site.servers).Msg("Starting NWWS-IO client")
+		g.Go(func() error {
+			err := site.Run()
+			if err != nil {
+				// A fatal error such as bad credentials shuts everything down
+				cancel()
+			}
+			return err
+		})
+	}
+
+	// Supervisors don't watch the context, so stop any still running once
+	// it's cancelled or Wait would block on them forever
 	g.Go(func() error {
-		return c.NWWSClient.Run()
+		<-gctx.Done()
+		for _, site := range c.sites {
+			site.Stop()
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		c.runWatchdog(gctx)
+		return nil
 	})
 
+	if c.metricsAddr != "" {
+		g.Go(func() error {
+			return serveMetrics(gctx, c.metricsAddr)
+		})
+	}
+
 	log.Info().Msg("Starting seabird command handler")
 	g.Go(func() error {
 		c.handleCommandEvents(gctx)
@@ -0,0 +1,603 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-go/pb"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// areaTypes maps area subscription types to their UGC type character
+var areaTypes = map[string]string{
+	"county": "C",
+	"zone":   "Z",
+}
+
+func buildFilterConfirmation(target Subscriber, stationCode string, filters []string) string {
+	var hasAll, hasCAP bool
+	var categories, selectors, constraints []string
+
+	for _, f := range filters {
+		if _, ok, _ := parseConstraint(f); ok {
+			constraints = append(constraints, describeConstraint(f))
+			continue
+		}
+		if description, ok := describeSelector(f); ok {
+			selectors = append(selectors, description)
+			continue
+		}
+		switch strings.ToLower(f) {
+		case "all":
+			hasAll = true
+		case "cap":
+			hasCAP = true
+		case "emergency":
+			selectors = append(selectors, "PDS and emergency alerts")
+		default:
+			categories = append(categories, f)
+		}
+	}
+
+	hasAll = hasAll || (!hasCAP && len(categories) == 0 && len(selectors) == 0)
+	confirmation := buildSelectorConfirmation(target, stationCode, hasAll, hasCAP, categories, selectors)
+	if len(constraints) > 0 {
+		confirmation += fmt.Sprintf(" Only products %s are sent.", joinWithAnd(constraints))
+	}
+	return confirmation
+}
+
+// buildSelectorConfirmation describes which products the selector filters
+// choose. selectors are descriptions of the emergency, VTEC and pil selectors.
+func buildSelectorConfirmation(target Subscriber, stationCode string, hasAll, hasCAP bool, categories, selectors []string) string {
+	recipient := "You'll receive DMs"
+	if target.IsChannel() {
+		recipient = "This channel will receive messages"
+	}
+
+	if hasAll {
+		return fmt.Sprintf("%s for ALL weather products from %s.", recipient, stationCode)
+	}
+	if hasCAP && len(categories) == 0 && len(selectors) == 0 {
+		return fmt.Sprintf("%s for emergency alerts (CAP) from %s.", recipient, stationCode)
+	}
+
+	var chosen []string
+	if hasCAP {
+		chosen = append(chosen, "CAP alerts")
+	}
+	if len(categories) > 0 {
+		chosen = append(chosen, strings.Join(categories, ", ")+" products")
+	}
+	chosen = append(chosen, selectors...)
+	return fmt.Sprintf("%s for %s from %s.", recipient, joinWithAnd(chosen), stationCode)
+}
+
+// joinWithAnd joins items as "a", "a and b" or "a, b and c"
+func joinWithAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// isAdmin reports whether a user is a configured plugin admin
+func (c *SeabirdClient) isAdmin(userID string) bool {
+	return c.admins[userID]
+}
+
+// canManageChannelSubscriptions reports whether a user may add or remove
+// channel subscriptions. Only admins may, so nobody can when none are
+// configured.
+func (c *SeabirdClient) canManageChannelSubscriptions(userID string) bool {
+	return c.isAdmin(userID)
+}
+
+// resolveSubscriber determines whether a subscription command applies to the
+// calling user or, with a leading "channel" argument, to the channel the
+// command was issued in. It returns the remaining arguments.
+func resolveSubscriber(cmd *pb.CommandEvent, args []string) (Subscriber, []string) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "channel" {
+		return Subscriber{ChannelID: cmd.Source.ChannelId}, args[1:]
+	}
+	return Subscriber{UserID: cmd.Source.User.Id}, args
+}
+
+// sendConfirmation sends a subscription confirmation by DM for users, or to the channel for channel subscriptions
+func (c *SeabirdClient) sendConfirmation(cmd *pb.CommandEvent, target Subscriber, msg string) {
+	if target.IsChannel() {
+		c.SendMessage(cmd.Source.ChannelId, msg)
+		return
+	}
+	c.SendPrivateMessage(cmd.Source.User.Id, msg)
+}
+
+func (c *SeabirdClient) handleNoaaCommand(event *pb.Event, cmd *pb.CommandEvent) {
+	log.Info().
+		Str("user_id", cmd.Source.User.Id).
+		Str("user_name", cmd.Source.User.DisplayName).
+		Str("channel_id", cmd.Source.ChannelId).
+		Str("command", cmd.Command).
+		Str("arg", cmd.Arg).
+		Msg("Received !noaa command")
+
+	args := strings.Fields(cmd.Arg)
+	if len(args) < 1 {
+		c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa <subscribe|unsubscribe|list> <station|zip> [code]")
+		return
+	}
+
+	action := strings.ToLower(args[0])
+
+	switch action {
+	case "help":
+		helpMsg := "NOAA Weather Alerts: !noaa subscribe <station|zone|county|same> <CODE> [filters...] | subscribe point <LAT,LON> [label] [filters...] | unsubscribe <station|zone|county|same> <CODE> | unsubscribe point <label> | unsubscribe all | list | recent <CODE> | show <ID> [page] | filters | gaps (admin) | status (admin) | help. Prefix subscribe/unsubscribe/list with 'channel' to manage this channel's feed. Example: !noaa subscribe station KJAX warning, !noaa subscribe channel zone MIZ068"
+		c.SendMessage(cmd.Source.ChannelId, helpMsg)
+
+	case "filters":
+		validFilters := GetValidFilters()
+		msg := "Valid filter options:\n"
+		msg += "Special: all, cap, emergency\n"
+		msg += "Categories: " + strings.Join(validFilters[3:], ", ") + "\n"
+		msg += "VTEC events: <phenomena>.<significance> with * for any (e.g. TO.W, SV.*, *.A) | AWIPS IDs: pil:<NNN> (e.g. pil:AFD)\n"
+		msg += "Threat tags (narrow the filters above, or all products alone): tornado:<possible|radar|observed>, waterspout:<...>, flood:<...>, hail>=<inches>, wind>=<mph>, damage>=<considerable|destructive|catastrophic>\n"
+		msg += "CAP thresholds (CAP alerts only): severity>=<minor|moderate|severe|extreme>, urgency>=<past|future|expected|immediate>, certainty>=<unlikely|possible|likely|observed>"
+		c.SendMessage(cmd.Source.ChannelId, msg)
+
+	case "subscribe":
+		c.handleSubscribe(cmd, args[1:])
+
+	case "unsubscribe":
+		c.handleUnsubscribe(cmd, args[1:])
+
+	case "recent":
+		if len(args) < 2 {
+			c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa recent <station_code>")
+			return
+		}
+		stationCode := strings.ToUpper(args[1])
+		messages := c.recentMessages(stationCode)
+
+		if len(messages) == 0 {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("No recent messages from %s", stationCode))
+			return
+		}
+
+		var msg strings.Builder
+		msg.WriteString(fmt.Sprintf("Recent messages from %s:\n", stationCode))
+		for i, m := range messages {
+			ago := time.Since(m.Timestamp).Round(time.Second)
+			awipsID := m.AwipsID
+			if m.BBB != "" {
+				awipsID += " " + m.BBB
+			}
+			msg.WriteString(fmt.Sprintf("%d. %s - %s (%s ago)\n", i+1, m.DataType, awipsID, ago))
+		}
+		c.SendMessage(cmd.Source.ChannelId, msg.String())
+
+	case "list":
+		c.handleList(cmd, args[1:])
+
+	case "show":
+		c.handleShow(cmd, args[1:])
+
+	case "gaps":
+		c.handleGaps(cmd)
+
+	case "status":
+		c.handleStatus(cmd)
+
+	default:
+		c.SendMessage(cmd.Source.ChannelId, "Unknown action. Use: subscribe, unsubscribe, or list")
+	}
+}
+
+// isFilterArg reports whether a command argument is a comma-separated list of
+// valid filters
+func isFilterArg(arg string) bool {
+	var filters []string
+	for _, part := range strings.Split(arg, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			filters = append(filters, trimmed)
+		}
+	}
+	return len(filters) > 0 && len(ValidateFilters(filters)) == 0
+}
+
+func (c *SeabirdClient) handleSubscribe(cmd *pb.CommandEvent, args []string) {
+	target, args := resolveSubscriber(cmd, args)
+	if target.IsChannel() && !c.canManageChannelSubscriptions(cmd.Source.User.Id) {
+		c.SendMessage(cmd.Source.ChannelId, "Only plugin admins may add channel subscriptions")
+		return
+	}
+
+	if len(args) < 2 {
+		c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa subscribe [channel] <station|zone|county|same> <code> [filters...] or !noaa subscribe [channel] point <lat,lon> [label] [filters...]")
+		c.SendMessage(cmd.Source.ChannelId, "Filters: cap (default), all, emergency, or any product category")
+		c.SendMessage(cmd.Source.ChannelId, "Use '!noaa filters' to see all valid filter options")
+		return
+	}
+	subType := strings.ToLower(args[0])
+	code := args[1]
+
+	// Point subscriptions take an optional label before the filters. A
+	// first word that is a valid filter is a filter, not a label.
+	filterArgs := args[2:]
+	label := code
+	if subType == "point" && len(filterArgs) > 0 && !isFilterArg(filterArgs[0]) {
+		label = filterArgs[0]
+		filterArgs = filterArgs[1:]
+	}
+
+	var filters []string
+	for _, arg := range filterArgs {
+		for _, part := range strings.Split(arg, ",") {
+			if trimmed := strings.TrimSpace(part); trimmed != "" {
+				filters = append(filters, trimmed)
+			}
+		}
+	}
+	if len(filters) == 0 {
+		filters = []string{"cap"}
+	}
+
+	// Validate filters before subscribing
+	if invalidFilters := ValidateFilters(filters); len(invalidFilters) > 0 {
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid filter(s): %s", strings.Join(invalidFilters, ", ")))
+		c.SendMessage(cmd.Source.ChannelId, "Use '!noaa filters' to see all valid filter options")
+		return
+	}
+
+	sub := Subscription{Subscriber: target, Filters: filters}
+	if target.IsChannel() {
+		sub.AddedBy = cmd.Source.User.Id
+	}
+
+	switch subType {
+	case "station":
+		if err := ValidateStationCode(code); err != nil {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid station code: %s", err))
+			return
+		}
+
+		c.subscriptions.SubscribeToStation(code, sub)
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Subscribed to station %s with filters: %s", strings.ToUpper(code), strings.Join(filters, ", ")))
+
+		confirmMsg := buildFilterConfirmation(target, strings.ToUpper(code), filters)
+		recent := c.recentMessages(code)
+		if len(recent) > 0 {
+			lastMsg := recent[len(recent)-1]
+			confirmMsg += fmt.Sprintf("\nLast activity: %s (%s ago)",
+				lastMsg.DataType,
+				time.Since(lastMsg.Timestamp).Round(time.Second))
+		}
+		c.sendConfirmation(cmd, target, confirmMsg)
+
+	case "zone", "county":
+		ugc, err := ValidateAreaCode(code, areaTypes[subType])
+		if err != nil {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid %s code: %s", subType, err))
+			return
+		}
+
+		c.subscriptions.SubscribeToArea(ugc, sub)
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Subscribed to %s %s with filters: %s", subType, ugc, strings.Join(filters, ", ")))
+		c.sendConfirmation(cmd, target, buildFilterConfirmation(target, fmt.Sprintf("%s %s", subType, ugc), filters))
+
+	case "same":
+		same, err := nwwsio.ParseSAMECode(code)
+		if err != nil {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("%s. Use PSSCCC, e.g. 026163 for a county or 026000 for a whole state", err))
+			return
+		}
+
+		c.subscriptions.SubscribeToSAME(same, sub)
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Subscribed to SAME %s with filters: %s", same, strings.Join(filters, ", ")))
+		c.sendConfirmation(cmd, target, buildFilterConfirmation(target, fmt.Sprintf("SAME %s", same), filters))
+
+	case "point":
+		location, err := nwwsio.ParsePoint(code)
+		if err != nil {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid point: %s. Use <lat,lon> without spaces, e.g. 42.33,-83.05", err))
+			return
+		}
+
+		c.subscriptions.SubscribeToPoint(label, location, sub)
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Subscribed to point %s (%s) with filters: %s", label, location, strings.Join(filters, ", ")))
+		c.sendConfirmation(cmd, target, buildFilterConfirmation(target, fmt.Sprintf("warnings covering %s", label), filters))
+
+	default:
+		c.SendMessage(cmd.Source.ChannelId, "Invalid subscription type. Use 'station', 'zone', 'county', 'same' or 'point'")
+		return
+	}
+
+	log.Info().
+		Str("subscriber", target.String()).
+		Bool("channel", target.IsChannel()).
+		Str("added_by", cmd.Source.User.Id).
+		Str("type", subType).
+		Str("code", code).
+		Strs("filters", filters).
+		Msg("Added subscription")
+}
+
+func (c *SeabirdClient) handleUnsubscribe(cmd *pb.CommandEvent, args []string) {
+	target, args := resolveSubscriber(cmd, args)
+	if target.IsChannel() && !c.canManageChannelSubscriptions(cmd.Source.User.Id) {
+		c.SendMessage(cmd.Source.ChannelId, "Only plugin admins may remove channel subscriptions")
+		return
+	}
+
+	if len(args) < 1 {
+		c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa unsubscribe [channel] <station|zone|county|same|point|all> [code|label]")
+		return
+	}
+	subType := strings.ToLower(args[0])
+
+	if subType == "all" {
+		count := c.subscriptions.UnsubscribeFromAll(target)
+		if count > 0 {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Removed %d subscription(s)", count))
+		} else if target.IsChannel() {
+			c.SendMessage(cmd.Source.ChannelId, "This channel has no active subscriptions")
+		} else {
+			c.SendMessage(cmd.Source.ChannelId, "You have no active subscriptions")
+		}
+		return
+	}
+
+	if len(args) < 2 {
+		c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa unsubscribe [channel] <station|zone|county|same|point> <code|label>")
+		return
+	}
+	code := args[1]
+
+	switch subType {
+	case "station":
+		if c.subscriptions.UnsubscribeFromStation(target, code) {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Unsubscribed from station %s", strings.ToUpper(code)))
+		} else {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Not subscribed to station %s", strings.ToUpper(code)))
+		}
+
+	case "zone", "county":
+		ugc, err := ValidateAreaCode(code, areaTypes[subType])
+		if err != nil {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid %s code: %s", subType, err))
+			return
+		}
+
+		if c.subscriptions.UnsubscribeFromArea(target, ugc) {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Unsubscribed from %s %s", subType, ugc))
+		} else {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Not subscribed to %s %s", subType, ugc))
+		}
+
+	case "same":
+		same, err := nwwsio.ParseSAMECode(code)
+		if err != nil {
+			c.SendMessage(cmd.Source.ChannelId, err.Error())
+			return
+		}
+
+		if c.subscriptions.UnsubscribeFromSAME(target, same) {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Unsubscribed from SAME %s", same))
+		} else {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Not subscribed to SAME %s", same))
+		}
+
+	case "point":
+		if c.subscriptions.UnsubscribeFromPoint(target, code) {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Unsubscribed from point %s", code))
+		} else {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Not subscribed to point %s", code))
+		}
+
+	default:
+		c.SendMessage(cmd.Source.ChannelId, "Invalid subscription type. Use 'station', 'zone', 'county', 'same', 'point' or 'all'")
+	}
+}
+
+func (c *SeabirdClient) handleList(cmd *pb.CommandEvent, args []string) {
+	target, _ := resolveSubscriber(cmd, args)
+
+	stations := c.subscriptions.GetUserStationSubscriptions(target)
+	areas := c.subscriptions.GetUserAreaSubscriptions(target)
+	points := c.subscriptions.GetUserPointSubscriptions(target)
+	same := c.subscriptions.GetUserSAMESubscriptions(target)
+
+	msg := "Your subscriptions:\n"
+	if target.IsChannel() {
+		msg = "Channel subscriptions:\n"
+	}
+	if len(stations) > 0 {
+		msg += fmt.Sprintf("Stations: %s\n", formatUserSubscriptions(stations))
+	}
+	if len(areas) > 0 {
+		msg += fmt.Sprintf("Counties/Zones: %s\n", formatUserSubscriptions(areas))
+	}
+	if len(same) > 0 {
+		msg += fmt.Sprintf("SAME: %s\n", formatUserSubscriptions(same))
+	}
+	if len(points) > 0 {
+		labels := make([]string, 0, len(points))
+		for _, point := range points {
+			labels = append(labels, fmt.Sprintf("%s (%s) [%s]", point.Label, point.Location, strings.Join(point.Filters, ", ")))
+		}
+		msg += fmt.Sprintf("Points: %s\n", strings.Join(labels, ", "))
+	}
+	if len(stations) == 0 && len(areas) == 0 && len(same) == 0 && len(points) == 0 {
+		if target.IsChannel() {
+			msg = "This channel has no active subscriptions"
+		} else {
+			msg = "You have no active subscriptions"
+		}
+	}
+
+	c.SendMessage(cmd.Source.ChannelId, msg)
+}
+
+// formatUserSubscriptions lists subscriptions with their filters (e.g.,
+// "KDTX [warning, severity>=severe], KGRR [cap]")
+func formatUserSubscriptions(subs []UserSubscription) string {
+	entries := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		entries = append(entries, fmt.Sprintf("%s [%s]", sub.Key, strings.Join(sub.Filters, ", ")))
+	}
+	return strings.Join(entries, ", ")
+}
+
+// recentMessages returns the latest products from a station, oldest first.
+// The archive is used when enabled so history survives restarts.
+func (c *SeabirdClient) recentMessages(stationCode string) []RecentMessage {
+	if c.archive == nil {
+		return c.subscriptions.GetRecentMessages(stationCode)
+	}
+
+	// Fetch extra products so superseded versions can be dropped
+	products, err := c.archive.Query(ArchiveQuery{Station: stationCode, Limit: 2 * MaxRecentMessages})
+	if err != nil {
+		log.Error().Err(err).Str("station", stationCode).Msg("Failed to query product archive")
+		return c.subscriptions.GetRecentMessages(stationCode)
+	}
+
+	var messages []RecentMessage
+	for i := len(products) - 1; i >= 0; i-- {
+		product := products[i]
+		messages, _, _ = appendRecentMessage(messages, RecentMessage{
+			ID:        product.ID,
+			Station:   product.Station,
+			DataType:  product.DisplayName,
+			AwipsID:   product.AwipsID,
+			Issue:     product.Issue,
+			Text:      product.Text,
+			Timestamp: product.Received,
+			Heading:   product.Heading,
+			BBB:       product.BBB,
+		})
+	}
+	return messages
+}
+
+// handleShow DMs one page of a stored product's full text
+func (c *SeabirdClient) handleShow(cmd *pb.CommandEvent, args []string) {
+	if len(args) < 1 {
+		c.SendMessage(cmd.Source.ChannelId, "Usage: !noaa show <id> [page]")
+		return
+	}
+	id := args[0]
+
+	page := 1
+	if len(args) > 1 {
+		parsed, err := strconv.Atoi(args[1])
+		if err != nil || parsed < 1 {
+			c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Invalid page number: %s", args[1]))
+			return
+		}
+		page = parsed
+	}
+
+	product := c.findProduct(id)
+	if product == nil {
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("No stored product with ID %s", id))
+		return
+	}
+
+	pages := paginateText(product.Text, ShowPageLen)
+	if len(pages) == 0 {
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Product %s has no text", id))
+		return
+	}
+	if page > len(pages) {
+		c.SendMessage(cmd.Source.ChannelId, fmt.Sprintf("Product %s only has %d page(s)", id, len(pages)))
+		return
+	}
+
+	msg := fmt.Sprintf("[%s] %s - %s (page %d/%d)\n\n%s", product.Station, product.DisplayName, product.AwipsID, page, len(pages), pages[page-1])
+	if page < len(pages) {
+		msg += fmt.Sprintf("\n\nNext page: !noaa show %s %d", id, page+1)
+	}
+	c.SendPrivateMessage(cmd.Source.User.Id, msg)
+}
+
+// findProduct looks up a stored product by its NWWS ID, checking the archive
+// and then recent history
+func (c *SeabirdClient) findProduct(id string) *ArchivedProduct {
+	if c.archive != nil {
+		product, err := c.archive.Get(id)
+		if err != nil {
+			log.Error().Err(err).Str("id", id).Msg("Failed to read product from archive")
+		} else if product != nil {
+			return product
+		}
+	}
+
+	msg, ok := c.subscriptions.FindRecentMessage(id)
+	if !ok {
+		return nil
+	}
+	return &ArchivedProduct{
+		ID:          msg.ID,
+		Received:    msg.Timestamp,
+		Station:     msg.Station,
+		AwipsID:     msg.AwipsID,
+		Issue:       msg.Issue,
+		DisplayName: msg.DataType,
+		Heading:     msg.Heading,
+		BBB:         msg.BBB,
+		Text:        msg.Text,
+	}
+}
+
+// handleGaps reports sequence gap statistics and the most recent missed ranges. Admin only.
+func (c *SeabirdClient) handleGaps(cmd *pb.CommandEvent) {
+	if !c.isAdmin(cmd.Source.User.Id) {
+		c.SendMessage(cmd.Source.ChannelId, "Only plugin admins may view sequence gaps")
+		return
+	}
+
+	stats := c.gaps.Stats()
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("Sequence gaps: %d | Missing: %d | Late: %d | Duplicates: %d | Restarts: %d",
+		stats.Gaps, stats.Missed, stats.Late, stats.Duplicates, stats.Restarts))
+
+	gaps := c.gaps.RecentGaps(MaxGapsShown)
+	if len(gaps) > 0 {
+		msg.WriteString("\nRecent missed ranges:")
+		for _, gap := range gaps {
+			msg.WriteString("\n" + gap.String())
+		}
+	}
+	c.SendMessage(cmd.Source.ChannelId, msg.String())
+}
+
+// handleStatus reports the state of each NWWS-OI connection and its recent transitions. Admin only.
+func (c *SeabirdClient) handleStatus(cmd *pb.CommandEvent) {
+	if !c.isAdmin(cmd.Source.User.Id) {
+		c.SendMessage(cmd.Source.ChannelId, "Only plugin admins may view connection status")
+		return
+	}
+
+	var msg strings.Builder
+	for i, site := range c.sites {
+		if i > 0 {
+			msg.WriteString("\n")
+		}
+		state, server := site.State()
+		lastProduct, lastKeepAlive := c.watchdog.LastHeard(site.name)
+		msg.WriteString(fmt.Sprintf("Site %s: %s %s | Last product: %s | Last keep-alive: %s",
+			site.name, state, server, formatWatchdogTime(lastProduct), formatWatchdogTime(lastKeepAlive)))
+
+		history := site.History()
+		if len(history) > MaxTransitionsShown {
+			history = history[len(history)-MaxTransitionsShown:]
+		}
+		for _, transition := range history {
+			msg.WriteString("\n  " + transition.String())
+		}
+	}
+	c.SendMessage(cmd.Source.ChannelId, msg.String())
+}
@@ -0,0 +1,451 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+const (
+	testChannel = "#weather"
+	testUser    = "alice"
+	testAdmin   = "admin"
+)
+
+// commandStep runs a !noaa command, or delivers a product when product is
+// set, and expects exactly the listed messages in order
+type commandStep struct {
+	user    string // defaults to testUser
+	arg     string
+	product *fakeProduct
+	want    []sentMessage // Text is matched as a substring
+}
+
+// channelMsg expects a message to the test channel
+func channelMsg(text string) sentMessage {
+	return sentMessage{Target: testChannel, Text: text}
+}
+
+// privateMsg expects a private message to a user
+func privateMsg(userID, text string) sentMessage {
+	return sentMessage{Private: true, Target: userID, Text: text}
+}
+
+// startCommandClient connects a client to a fake seabird-core and handles its
+// command events until the test finishes
+func startCommandClient(t *testing.T, config Config) (*SeabirdClient, *fakeSeabirdCore) {
+	t.Helper()
+
+	core := newFakeSeabirdCore(t)
+	config.SeabirdCoreURL = core.URL()
+	config.ArchiveDir = t.TempDir()
+
+	client, err := NewSeabirdClient(config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.handleCommandEvents(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		_ = client.Shutdown()
+	})
+
+	commands := core.WaitForStream()
+	if _, ok := commands["noaa"]; !ok {
+		t.Fatalf("noaa command not registered, got %v", commands)
+	}
+	return client, core
+}
+
+// deliverProduct feeds a product through the message handling as if it
+// arrived from NWWS-OI
+func deliverProduct(client *SeabirdClient, p fakeProduct) {
+	ext := &nwwsio.NWWSOIMessageXExtension{
+		ID:      p.ID,
+		Cccc:    p.Cccc,
+		Ttaaii:  p.Ttaaii,
+		Issue:   p.Issue,
+		AwipsID: p.AwipsID,
+		Text:    p.Text,
+	}
+	client.pipeline.HandleProduct("test", ext, time.Now())
+}
+
+// runSteps runs each step and checks the messages it sent
+func runSteps(t *testing.T, client *SeabirdClient, core *fakeSeabirdCore, steps []commandStep) {
+	t.Helper()
+
+	for i, step := range steps {
+		offset := len(core.Sent())
+		if step.product != nil {
+			// Delivery is synchronous, everything has been sent on return
+			deliverProduct(client, *step.product)
+		} else {
+			user := step.user
+			if user == "" {
+				user = testUser
+			}
+			core.SendCommand(user, testChannel, "noaa", step.arg)
+		}
+
+		got := core.WaitForSent(offset, len(step.want))
+		for j, want := range step.want {
+			if got[j].Private != want.Private || got[j].Target != want.Target || !strings.Contains(got[j].Text, want.Text) {
+				t.Errorf("step %d (%q) message %d:\ngot  %+v\nwant %+v", i, step.arg, j, got[j], want)
+			}
+		}
+	}
+
+	// Commands are handled in order, so anything left over from the last
+	// step would arrive before the reply to this one
+	offset := len(core.Sent())
+	core.SendCommand(testUser, testChannel, "noaa", "help")
+	if got := core.WaitForSent(offset, 1); !strings.Contains(got[0].Text, "NOAA Weather Alerts") {
+		t.Errorf("unexpected extra message %+v", got[0])
+	}
+}
+
+func productStep(p fakeProduct, want ...sentMessage) commandStep {
+	return commandStep{product: &p, want: want}
+}
+
+// longProduct returns a product whose text spans two show pages
+func longProduct(id string) fakeProduct {
+	p := tornadoWarning(id)
+	p.AwipsID = "AFDOUN"
+	p.Ttaaii = "FXUS64"
+	p.Text = strings.Repeat("The forecast discussion continues on this line.\n", 40)
+	return p
+}
+
+// keepAlive returns an NWWS-OI keep-alive product with the given ID
+func keepAlive(id string) fakeProduct {
+	return fakeProduct{ID: id, Cccc: "KOUN", Ttaaii: "NOUS44", Issue: "2026-05-06T22:14:00Z", AwipsID: "KPAOUN", Text: "keep-alive"}
+}
+
+// tornadoContinuation returns a follow-up to tornadoWarning's event
+func tornadoContinuation(id string) fakeProduct {
+	p := tornadoWarning(id)
+	p.Ttaaii = "WWUS54"
+	p.AwipsID = "SVSOUN"
+	p.Text = strings.Replace(p.Text, "/O.NEW.", "/O.CON.", 1)
+	p.Text = strings.Replace(p.Text, "TOROUN", "SVSOUN", 1)
+	return p
+}
+
+func TestNoaaCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		noAdmins bool
+		steps    []commandStep
+	}{
+		{name: "no arguments", steps: []commandStep{
+			{arg: "", want: []sentMessage{channelMsg("Usage: !noaa")}},
+		}},
+		{name: "help", steps: []commandStep{
+			{arg: "help", want: []sentMessage{channelMsg("show <ID> [page]")}},
+		}},
+		{name: "filters", steps: []commandStep{
+			{arg: "filters", want: []sentMessage{channelMsg("Valid filter options")}},
+		}},
+		{name: "unknown action", steps: []commandStep{
+			{arg: "frobnicate", want: []sentMessage{channelMsg("Unknown action")}},
+		}},
+		{name: "subscribe usage", steps: []commandStep{
+			{arg: "subscribe station", want: []sentMessage{
+				channelMsg("Usage: !noaa subscribe"),
+				channelMsg("Filters: cap (default)"),
+				channelMsg("Use '!noaa filters'"),
+			}},
+		}},
+		{name: "subscribe station", steps: []commandStep{
+			{arg: "subscribe station kjax warning", want: []sentMessage{
+				channelMsg("Subscribed to station KJAX with filters: warning"),
+				privateMsg(testUser, "You'll receive DMs for warning products from KJAX."),
+			}},
+			{arg: "list", want: []sentMessage{channelMsg("Stations: KJAX [warning]")}},
+		}},
+		{name: "subscribe station defaults to cap", steps: []commandStep{
+			{arg: "subscribe station KJAX", want: []sentMessage{
+				channelMsg("with filters: cap"),
+				privateMsg(testUser, "emergency alerts (CAP) from KJAX"),
+			}},
+		}},
+		{name: "subscribe station with comma separated filters", steps: []commandStep{
+			{arg: "subscribe station KJAX cap,watch", want: []sentMessage{
+				channelMsg("with filters: cap, watch"),
+				privateMsg(testUser, "CAP alerts and watch products from KJAX"),
+			}},
+		}},
+		{name: "subscribe station reports last activity", steps: []commandStep{
+			productStep(tornadoWarning("1001.1")),
+			{arg: "subscribe station KOUN all", want: []sentMessage{
+				channelMsg("Subscribed to station KOUN"),
+				privateMsg(testUser, "Last activity: Tornado Warning"),
+			}},
+		}},
+		{name: "subscribe invalid filter", steps: []commandStep{
+			{arg: "subscribe station KJAX bogus", want: []sentMessage{
+				channelMsg("Invalid filter(s): bogus"),
+				channelMsg("Use '!noaa filters'"),
+			}},
+		}},
+		{name: "subscribe invalid station", steps: []commandStep{
+			{arg: "subscribe station K1", want: []sentMessage{channelMsg("Invalid station code")}},
+		}},
+		{name: "subscribe invalid type", steps: []commandStep{
+			{arg: "subscribe planet mars", want: []sentMessage{channelMsg("Invalid subscription type")}},
+		}},
+		{name: "subscribe zone", steps: []commandStep{
+			{arg: "subscribe zone miz068 all", want: []sentMessage{
+				channelMsg("Subscribed to zone MIZ068 with filters: all"),
+				privateMsg(testUser, "ALL weather products from zone MIZ068"),
+			}},
+			{arg: "list", want: []sentMessage{channelMsg("Counties/Zones: MIZ068")}},
+		}},
+		{name: "subscribe county with zone code", steps: []commandStep{
+			{arg: "subscribe county MIZ068", want: []sentMessage{channelMsg("Invalid county code")}},
+		}},
+		{name: "subscribe same", steps: []commandStep{
+			{arg: "subscribe same 026163 warning", want: []sentMessage{
+				channelMsg("Subscribed to SAME 026163 with filters: warning"),
+				privateMsg(testUser, "warning products from SAME 026163"),
+			}},
+			{arg: "list", want: []sentMessage{channelMsg("SAME: 026163 [warning]")}},
+		}},
+		{name: "subscribe with vtec and pil selectors", steps: []commandStep{
+			{arg: "subscribe station KOUN TO.W sv.* pil:afd", want: []sentMessage{
+				channelMsg("with filters: TO.W, sv.*, pil:afd"),
+				privateMsg(testUser, "You'll receive DMs for VTEC Tornado Warning, any VTEC Severe Thunderstorm event and AWIPS ID AFD* from KOUN."),
+			}},
+			{arg: "subscribe station KDTX warning *.A", want: []sentMessage{
+				channelMsg("with filters: warning, *.A"),
+				privateMsg(testUser, "You'll receive DMs for warning products and any VTEC Watch from KDTX."),
+			}},
+			{arg: "subscribe station KJAX cap *.*", want: []sentMessage{
+				channelMsg("with filters: cap, *.*"),
+				privateMsg(testUser, "You'll receive DMs for CAP alerts and any VTEC event from KJAX."),
+			}},
+		}},
+		{name: "subscribe with emergency and constraints", steps: []commandStep{
+			{arg: "subscribe station KOUN emergency warning certainty>=observed hail>=1.5", want: []sentMessage{
+				channelMsg("with filters: emergency, warning, certainty>=observed, hail>=1.5"),
+				privateMsg(testUser, "You'll receive DMs for warning products and PDS and emergency alerts from KOUN. "+
+					"Only products with CAP certainty Observed or higher and matching hail>=1.5 are sent."),
+			}},
+		}},
+		{name: "subscribe with cap thresholds", steps: []commandStep{
+			{arg: "subscribe zone MIZ068 cap severity>=Severe urgency>=expected", want: []sentMessage{
+				channelMsg("with filters: cap, severity>=Severe, urgency>=expected"),
+				privateMsg(testUser, "You'll receive DMs for emergency alerts (CAP) from zone MIZ068. "+
+					"Only products with CAP severity Severe or higher and with CAP urgency Expected or higher are sent."),
+			}},
+			{arg: "list", want: []sentMessage{channelMsg("Counties/Zones: MIZ068 [cap, severity>=severe, urgency>=expected]")}},
+		}},
+		{name: "subscribe invalid same", steps: []commandStep{
+			{arg: "subscribe same 12", want: []sentMessage{channelMsg("Use PSSCCC")}},
+		}},
+		{name: "subscribe point", steps: []commandStep{
+			{arg: "subscribe point 42.33,-83.05 home warning", want: []sentMessage{
+				channelMsg("Subscribed to point home"),
+				privateMsg(testUser, "warnings covering home"),
+			}},
+			{arg: "list", want: []sentMessage{channelMsg("Points: home (42.3300,-83.0500) [warning]")}},
+		}},
+		{name: "subscribe point without a label", steps: []commandStep{
+			{arg: "subscribe point 42.3,-83.0 warning", want: []sentMessage{
+				channelMsg("Subscribed to point 42.3,-83.0 (42.3000,-83.0000) with filters: warning"),
+				privateMsg(testUser, "warnings covering 42.3,-83.0"),
+			}},
+			{arg: "subscribe point 35.2,-97.4 warning,emergency", want: []sentMessage{
+				channelMsg("Subscribed to point 35.2,-97.4 (35.2000,-97.4000) with filters: warning, emergency"),
+				privateMsg(testUser, "35.2,-97.4"),
+			}},
+			{arg: "list", want: []sentMessage{channelMsg("Points: 42.3,-83.0 (42.3000,-83.0000) [warning], 35.2,-97.4 (35.2000,-97.4000) [warning, emergency]")}},
+		}},
+		{name: "subscribe invalid point", steps: []commandStep{
+			{arg: "subscribe point north", want: []sentMessage{channelMsg("Invalid point")}},
+		}},
+		{name: "channel subscribe by admin", steps: []commandStep{
+			{user: testAdmin, arg: "subscribe channel station KOUN all", want: []sentMessage{
+				channelMsg("Subscribed to station KOUN"),
+				channelMsg("This channel will receive messages for ALL weather products from KOUN."),
+			}},
+			{arg: "list channel", want: []sentMessage{channelMsg("Channel subscriptions:\nStations: KOUN")}},
+			{arg: "list", want: []sentMessage{channelMsg("You have no active subscriptions")}},
+		}},
+		{name: "channel subscribe by non-admin", steps: []commandStep{
+			{arg: "subscribe channel station KOUN", want: []sentMessage{channelMsg("Only plugin admins may add channel subscriptions")}},
+			{arg: "list channel", want: []sentMessage{channelMsg("This channel has no active subscriptions")}},
+		}},
+		{name: "channel subscribe without configured admins", noAdmins: true, steps: []commandStep{
+			{arg: "subscribe channel zone MIZ068", want: []sentMessage{channelMsg("Only plugin admins may add channel subscriptions")}},
+			{arg: "unsubscribe channel all", want: []sentMessage{channelMsg("Only plugin admins may remove channel subscriptions")}},
+			{arg: "list channel", want: []sentMessage{channelMsg("This channel has no active subscriptions")}},
+		}},
+		{name: "unsubscribe usage", steps: []commandStep{
+			{arg: "unsubscribe", want: []sentMessage{channelMsg("Usage: !noaa unsubscribe [channel] <station|zone|county|same|point|all>")}},
+			{arg: "unsubscribe station", want: []sentMessage{channelMsg("Usage: !noaa unsubscribe [channel] <station|zone|county|same|point>")}},
+		}},
+		{name: "unsubscribe station", steps: []commandStep{
+			{arg: "subscribe station KJAX", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KJAX")}},
+			{arg: "unsubscribe station kjax", want: []sentMessage{channelMsg("Unsubscribed from station KJAX")}},
+			{arg: "unsubscribe station kjax", want: []sentMessage{channelMsg("Not subscribed to station KJAX")}},
+		}},
+		{name: "unsubscribe area", steps: []commandStep{
+			{arg: "subscribe county OKC027", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "OKC027")}},
+			{arg: "unsubscribe county okc027", want: []sentMessage{channelMsg("Unsubscribed from county OKC027")}},
+			{arg: "unsubscribe county OKC027", want: []sentMessage{channelMsg("Not subscribed to county OKC027")}},
+			{arg: "unsubscribe zone 12", want: []sentMessage{channelMsg("Invalid zone code")}},
+		}},
+		{name: "unsubscribe same", steps: []commandStep{
+			{arg: "subscribe same 040027", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "040027")}},
+			{arg: "unsubscribe same 040027", want: []sentMessage{channelMsg("Unsubscribed from SAME 040027")}},
+			{arg: "unsubscribe same 040027", want: []sentMessage{channelMsg("Not subscribed to SAME 040027")}},
+			{arg: "unsubscribe same 12", want: []sentMessage{channelMsg("expected 6 digits")}},
+		}},
+		{name: "unsubscribe point", steps: []commandStep{
+			{arg: "subscribe point 35.2,-97.4 norman", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "norman")}},
+			{arg: "unsubscribe point norman", want: []sentMessage{channelMsg("Unsubscribed from point norman")}},
+			{arg: "unsubscribe point norman", want: []sentMessage{channelMsg("Not subscribed to point norman")}},
+		}},
+		{name: "unsubscribe all", steps: []commandStep{
+			{arg: "subscribe station KJAX", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KJAX")}},
+			{arg: "subscribe zone MIZ068", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "MIZ068")}},
+			{arg: "unsubscribe all", want: []sentMessage{channelMsg("Removed 2 subscription(s)")}},
+			{arg: "unsubscribe all", want: []sentMessage{channelMsg("You have no active subscriptions")}},
+		}},
+		{name: "unsubscribe invalid type", steps: []commandStep{
+			{arg: "unsubscribe planet mars", want: []sentMessage{channelMsg("Invalid subscription type")}},
+		}},
+		{name: "channel unsubscribe", steps: []commandStep{
+			{user: testAdmin, arg: "subscribe channel station KOUN", want: []sentMessage{channelMsg("Subscribed"), channelMsg("This channel")}},
+			{arg: "unsubscribe channel station KOUN", want: []sentMessage{channelMsg("Only plugin admins may remove channel subscriptions")}},
+			{user: testAdmin, arg: "unsubscribe channel all", want: []sentMessage{channelMsg("Removed 1 subscription(s)")}},
+			{user: testAdmin, arg: "unsubscribe channel all", want: []sentMessage{channelMsg("This channel has no active subscriptions")}},
+		}},
+		{name: "list empty", steps: []commandStep{
+			{arg: "list", want: []sentMessage{channelMsg("You have no active subscriptions")}},
+		}},
+		{name: "recent", steps: []commandStep{
+			{arg: "recent", want: []sentMessage{channelMsg("Usage: !noaa recent")}},
+			{arg: "recent KOUN", want: []sentMessage{channelMsg("No recent messages from KOUN")}},
+			productStep(tornadoWarning("1001.1")),
+			{arg: "recent koun", want: []sentMessage{channelMsg("Recent messages from KOUN:\n1. Tornado Warning")}},
+		}},
+		{name: "show", steps: []commandStep{
+			{arg: "show", want: []sentMessage{channelMsg("Usage: !noaa show")}},
+			{arg: "show 1001.1", want: []sentMessage{channelMsg("No stored product with ID 1001.1")}},
+			productStep(tornadoWarning("1001.1")),
+			{arg: "show 1001.1", want: []sentMessage{privateMsg(testUser, "[KOUN] Tornado Warning #42 from KOUN - TOROUN (page 1/1)")}},
+			{arg: "show 1001.1 x", want: []sentMessage{channelMsg("Invalid page number: x")}},
+			{arg: "show 1001.1 3", want: []sentMessage{channelMsg("Product 1001.1 only has 1 page(s)")}},
+		}},
+		{name: "show pages", steps: []commandStep{
+			productStep(longProduct("1002.1")),
+			{arg: "show 1002.1", want: []sentMessage{privateMsg(testUser, "Next page: !noaa show 1002.1 2")}},
+			{arg: "show 1002.1 2", want: []sentMessage{privateMsg(testUser, "(page 2/2)")}},
+		}},
+		{name: "gaps", steps: []commandStep{
+			{arg: "gaps", want: []sentMessage{channelMsg("Only plugin admins may view sequence gaps")}},
+			{user: testAdmin, arg: "gaps", want: []sentMessage{channelMsg("Sequence gaps: 0 | Missing: 0")}},
+			productStep(keepAlive("5001.1")),
+			productStep(keepAlive("5001.4")),
+			{user: testAdmin, arg: "gaps", want: []sentMessage{channelMsg("Sequence gaps: 1 | Missing: 2 | Late: 0 | Duplicates: 0 | Restarts: 0\nRecent missed ranges:\n5001.2-3")}},
+		}},
+		{name: "status", steps: []commandStep{
+			{arg: "status", want: []sentMessage{channelMsg("Only plugin admins may view connection status")}},
+			{user: testAdmin, arg: "status", want: []sentMessage{channelMsg("Site nwws: disconnected")}},
+			productStep(keepAlive("5001.1")),
+			{user: testAdmin, arg: "status", want: []sentMessage{channelMsg("Last keep-alive: ")}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Config{AdminUsers: []string{testAdmin}}
+			if tt.noAdmins {
+				config.AdminUsers = nil
+			}
+			client, core := startCommandClient(t, config)
+			runSteps(t, client, core, tt.steps)
+		})
+	}
+}
+
+func TestSubscriptionDelivery(t *testing.T) {
+	t.Parallel()
+
+	const alert = "ID: 1001.1 (full text: !noaa show 1001.1)"
+
+	tests := []struct {
+		name  string
+		steps []commandStep
+	}{
+		{name: "station subscriber gets a DM", steps: []commandStep{
+			{arg: "subscribe station KOUN warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KOUN")}},
+			productStep(tornadoWarning("1001.1"), privateMsg(testUser, "[KOUN] Tornado Warning")),
+		}},
+		{name: "channel subscriber gets a channel message", steps: []commandStep{
+			{user: testAdmin, arg: "subscribe channel county OKC027 warning", want: []sentMessage{channelMsg("Subscribed"), channelMsg("This channel")}},
+			productStep(tornadoWarning("1001.1"), channelMsg(alert)),
+		}},
+		{name: "point subscriber inside the polygon", steps: []commandStep{
+			{arg: "subscribe point 35.2,-97.4 norman warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "norman")}},
+			productStep(tornadoWarning("1001.1"), privateMsg(testUser, alert)),
+		}},
+		{name: "point subscriber outside the polygon", steps: []commandStep{
+			{arg: "subscribe point 42.33,-83.05 detroit warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "detroit")}},
+			productStep(tornadoWarning("1001.1")),
+		}},
+		{name: "filter mismatch", steps: []commandStep{
+			{arg: "subscribe station KOUN cap", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KOUN")}},
+			productStep(tornadoWarning("1001.1")),
+		}},
+		{name: "one copy for overlapping subscriptions", steps: []commandStep{
+			{arg: "subscribe station KOUN warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KOUN")}},
+			{arg: "subscribe county OKC027 all", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "OKC027")}},
+			{arg: "subscribe point 35.2,-97.4 norman warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "norman")}},
+			productStep(tornadoWarning("1001.1"), privateMsg(testUser, alert)),
+		}},
+		{name: "users and channels each get a copy", steps: []commandStep{
+			{arg: "subscribe station KOUN warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KOUN")}},
+			{user: "bob", arg: "subscribe county OKC087 all", want: []sentMessage{channelMsg("Subscribed"), privateMsg("bob", "OKC087")}},
+			{user: testAdmin, arg: "subscribe channel station KOUN all", want: []sentMessage{channelMsg("Subscribed"), channelMsg("This channel")}},
+			productStep(tornadoWarning("1001.1"), privateMsg(testUser, alert), channelMsg(alert), privateMsg("bob", alert)),
+		}},
+		{name: "follow-up products", steps: []commandStep{
+			{arg: "subscribe station KOUN all", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KOUN")}},
+			productStep(tornadoWarning("1001.1"), privateMsg(testUser, alert)),
+			productStep(tornadoContinuation("1001.2"), privateMsg(testUser, "Product: SVSOUN")),
+		}},
+		{name: "unsubscribed users get nothing", steps: []commandStep{
+			{arg: "subscribe station KOUN warning", want: []sentMessage{channelMsg("Subscribed"), privateMsg(testUser, "KOUN")}},
+			{arg: "unsubscribe all", want: []sentMessage{channelMsg("Removed 1 subscription(s)")}},
+			productStep(tornadoWarning("1001.1")),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, core := startCommandClient(t, Config{AdminUsers: []string{testAdmin}})
+			runSteps(t, client, core, tt.steps)
+		})
+	}
+}
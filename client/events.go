@@ -0,0 +1,582 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+const (
+	// How often expired events are pruned and the registry is saved
+	EventPruneInterval = 5 * time.Minute
+	// How long an event without an end time is kept after its last update
+	EventMaxIdleAge = 7 * 24 * time.Hour
+)
+
+// EventTransition describes how a product changed the state of a VTEC event
+type EventTransition string
+
+const (
+	EventNew       EventTransition = "new"
+	EventContinued EventTransition = "continued"
+	EventExtended  EventTransition = "extended"
+	EventUpgraded  EventTransition = "upgraded"
+	EventCancelled EventTransition = "cancelled"
+	EventExpired   EventTransition = "expired"
+	EventCorrected EventTransition = "corrected"
+)
+
+// ActiveEvent is a VTEC event currently tracked by the EventTracker
+type ActiveEvent struct {
+	Office       string
+	Phenomena    string
+	Significance string
+	ETN          int
+	Year         int
+	Begin        time.Time
+	End          time.Time
+	LastAction   string
+	Issued       time.Time // Time the NEW product was received
+	Updated      time.Time // Time the most recent product was received
+	ProductCount int
+
+	// The latest product about the event, see setProduct
+	Category string            `json:",omitempty"`
+	AwipsID  string            `json:",omitempty"`
+	UGC      []nwwsio.UGCCode  `json:",omitempty"`
+	SAME     []nwwsio.SAMECode `json:",omitempty"`
+	Polygons []nwwsio.Polygon  `json:",omitempty"`
+
+	product *productInfo // not saved, nil for events loaded from disk
+}
+
+// EventKey returns the registry key for an event (office+phenomena+significance+ETN+year)
+func EventKey(office, phenomena, significance string, etn, year int) string {
+	return fmt.Sprintf("%s.%s.%s.%04d.%d", office, phenomena, significance, etn, year)
+}
+
+// Key returns the registry key for this event
+func (e *ActiveEvent) Key() string {
+	return EventKey(e.Office, e.Phenomena, e.Significance, e.ETN, e.Year)
+}
+
+// EventName returns the friendly event name (e.g., "Tornado Warning #12")
+func (e *ActiveEvent) EventName() string {
+	vtec := nwwsio.VTEC{Phenomena: e.Phenomena, Significance: e.Significance}
+	return fmt.Sprintf("%s #%d", vtec.GetEventName(), e.ETN)
+}
+
+// EventUpdate is the result of applying a single P-VTEC code to the registry
+type EventUpdate struct {
+	Transition EventTransition
+	VTEC       nwwsio.VTEC
+	Event      ActiveEvent
+	Previous   *ActiveEvent // State before this update, nil if the event was unknown
+	UpgradedTo *nwwsio.VTEC // Event replacing an upgraded event, if in the same product
+}
+
+// EventTracker is a registry of active VTEC events that applies VTEC actions
+// as a state machine so continuations can be told apart from new events
+type EventTracker struct {
+	mu       sync.RWMutex
+	events   map[string]*ActiveEvent // event key -> event
+	filePath string                  // path to persistence file
+	stop     chan struct{}           // signal to stop the prune goroutine
+	stopOnce sync.Once
+}
+
+func NewEventTracker() *EventTracker {
+	return &EventTracker{
+		events: make(map[string]*ActiveEvent),
+		stop:   make(chan struct{}),
+	}
+}
+
+// SetPersistenceFile sets the file path for persistence
+func (et *EventTracker) SetPersistenceFile(filePath string) {
+	et.mu.Lock()
+	et.filePath = filePath
+	et.mu.Unlock()
+
+	log.Info().Str("file", filePath).Msg("Event persistence enabled")
+}
+
+// Start runs the prune goroutine which ages out expired events and periodically saves
+func (et *EventTracker) Start() {
+	go et.pruneLoop()
+}
+
+// Load reads active events from the persistence file
+func (et *EventTracker) Load() error {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	if et.filePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(et.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Info().Str("file", et.filePath).Msg("No existing event file found, starting fresh")
+			return nil
+		}
+		return fmt.Errorf("failed to read events file: %w", err)
+	}
+
+	var events map[string]*ActiveEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return fmt.Errorf("failed to parse events file: %w", err)
+	}
+	if events != nil {
+		et.events = events
+	}
+
+	log.Info().Str("file", et.filePath).Int("events", len(et.events)).Msg("Loaded active events from file")
+	return nil
+}
+
+// Save writes active events to disk atomically
+func (et *EventTracker) Save() error {
+	et.mu.RLock()
+	defer et.mu.RUnlock()
+
+	if et.filePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(et.events, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(et.filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmpFile := et.filePath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, et.filePath); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	log.Debug().Str("file", et.filePath).Msg("Saved active events to disk")
+	return nil
+}
+
+// Close stops the prune goroutine and performs a final save
+func (et *EventTracker) Close() error {
+	et.stopOnce.Do(func() {
+		close(et.stop)
+	})
+	return et.Save()
+}
+
+func (et *EventTracker) pruneLoop() {
+	ticker := time.NewTicker(EventPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-et.stop:
+			return
+		case now := <-ticker.C:
+			if removed := et.Prune(now); removed > 0 {
+				log.Info().Int("removed", removed).Msg("Pruned expired events")
+			}
+			if err := et.Save(); err != nil {
+				log.Error().Err(err).Msg("Failed to save active events")
+			}
+		}
+	}
+}
+
+// Prune removes events which have ended, or which have no end time and have
+// not been updated in EventMaxIdleAge, returning the number removed
+func (et *EventTracker) Prune(now time.Time) int {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	removed := 0
+	for key, event := range et.events {
+		expired := !event.End.IsZero() && now.After(event.End)
+		idle := event.End.IsZero() && now.Sub(event.Updated) > EventMaxIdleAge
+		if expired || idle {
+			delete(et.events, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Get returns a copy of an active event by key
+func (et *EventTracker) Get(key string) (ActiveEvent, bool) {
+	et.mu.RLock()
+	defer et.mu.RUnlock()
+
+	event, found := et.events[key]
+	if !found {
+		return ActiveEvent{}, false
+	}
+	return *event, true
+}
+
+// GetActiveEvents returns copies of all active events for an office, or all
+// offices when office is empty, sorted by issue time
+func (et *EventTracker) GetActiveEvents(office string) []ActiveEvent {
+	et.mu.RLock()
+	defer et.mu.RUnlock()
+
+	office = strings.ToUpper(office)
+	var result []ActiveEvent
+	for _, event := range et.events {
+		if office == "" || event.Office == office {
+			result = append(result, *event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Issued.Before(result[j].Issued)
+	})
+	return result
+}
+
+// setProduct records the latest product about an active event, along with
+// the counties, zones, SAME codes and polygons it covers. Unknown events are
+// ignored.
+func (et *EventTracker) setProduct(key string, info *productInfo) {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	if event, found := et.events[key]; found {
+		event.Category, event.AwipsID = info.productCategory, info.awipsID
+		event.UGC, event.SAME, event.Polygons = info.ugc, info.same, info.polygons
+		event.product = info
+	}
+}
+
+// productInfo returns the latest product about the event for matching against
+// subscriber filters. Events loaded from disk only have what was saved, so
+// CAP and threat tag filters never match them.
+func (e *ActiveEvent) productInfo() *productInfo {
+	if e.product != nil {
+		return e.product
+	}
+	return &productInfo{
+		productCategory: e.Category,
+		awipsID:         e.AwipsID,
+		vtec: []nwwsio.VTEC{{
+			ProductClass: "O",
+			Action:       e.LastAction,
+			Office:       e.Office,
+			Phenomena:    e.Phenomena,
+			Significance: e.Significance,
+			ETN:          e.ETN,
+			Begin:        e.Begin,
+			End:          e.End,
+		}},
+		ugc:      e.UGC,
+		same:     e.SAME,
+		polygons: e.Polygons,
+	}
+}
+
+// sameEvent reports whether two P-VTEC codes refer to the same event
+func sameEvent(a, b nwwsio.VTEC) bool {
+	return a.Office == b.Office && a.Phenomena == b.Phenomena && a.Significance == b.Significance && a.ETN == b.ETN
+}
+
+// ApplyProduct applies every operational P-VTEC code in a product to the
+// registry and returns the resulting updates in order. Test and experimental
+// codes are ignored.
+//
+// Segmented products repeat an event's code in every segment it appears in,
+// possibly with different actions for different areas (e.g., EXT for some
+// zones and CAN for the rest). Each event is applied once per product, one
+// update is returned per distinct action, and the event is only retired when
+// every code for it is CAN, EXP or UPG.
+func (et *EventTracker) ApplyProduct(vtecs []nwwsio.VTEC, received time.Time) []EventUpdate {
+	return et.ApplySegments([][]nwwsio.VTEC{vtecs}, received)
+}
+
+// ApplySegments is ApplyProduct for a product split into segments, given the
+// P-VTEC codes of each segment. Segments tell which areas a code covers,
+// which is needed to link an upgraded event to the one replacing it.
+func (et *EventTracker) ApplySegments(segments [][]nwwsio.VTEC, received time.Time) []EventUpdate {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	var order []string
+	codes := make(map[string][]nwwsio.VTEC)
+	for _, segment := range segments {
+		for _, vtec := range segment {
+			if !vtec.IsOperational() {
+				continue
+			}
+			key := fmt.Sprintf("%s.%s.%s.%04d", vtec.Office, vtec.Phenomena, vtec.Significance, vtec.ETN)
+			if _, seen := codes[key]; !seen {
+				order = append(order, key)
+			}
+			codes[key] = addEventCode(codes[key], vtec)
+		}
+	}
+
+	var updates []EventUpdate
+	for _, key := range order {
+		updates = append(updates, et.apply(codes[key], received)...)
+	}
+	linkUpgrades(updates, segments)
+
+	return updates
+}
+
+// linkUpgrades links upgraded events to their replacement for "upgraded to"
+// messaging. An upgrade is issued as an UPG of the old event alongside a NEW
+// for the replacement from the same office, in the segments for the same
+// areas. Upgrades sharing segments with more than one NEW event are left
+// unlinked rather than guessed.
+func linkUpgrades(updates []EventUpdate, segments [][]nwwsio.VTEC) {
+	for i := range updates {
+		if updates[i].Transition != EventUpgraded {
+			continue
+		}
+		upgraded := updates[i].VTEC
+
+		var replacement *nwwsio.VTEC
+		ambiguous := false
+		for _, segment := range segments {
+			if !hasEventAction(segment, upgraded, "UPG") {
+				continue
+			}
+			for _, code := range segment {
+				if code.Action != "NEW" || !code.IsOperational() || code.Office != upgraded.Office {
+					continue
+				}
+				if replacement == nil {
+					replacement = &code
+				} else if !sameEvent(*replacement, code) {
+					ambiguous = true
+				}
+			}
+		}
+		if replacement != nil && !ambiguous {
+			updates[i].UpgradedTo = replacement
+		}
+	}
+}
+
+// hasEventAction reports whether codes include an action for an event
+func hasEventAction(codes []nwwsio.VTEC, event nwwsio.VTEC, action string) bool {
+	for _, code := range codes {
+		if code.Action == action && sameEvent(code, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// addEventCode adds a code to an event's codes, merging repeats of an action
+// from other segments and keeping the latest end time
+func addEventCode(codes []nwwsio.VTEC, vtec nwwsio.VTEC) []nwwsio.VTEC {
+	for i := range codes {
+		if codes[i].Action == vtec.Action {
+			if vtec.End.After(codes[i].End) {
+				codes[i] = vtec
+			}
+			return codes
+		}
+	}
+	return append(codes, vtec)
+}
+
+// isTerminalAction reports whether a P-VTEC action ends the event in the
+// areas it covers
+func isTerminalAction(action string) bool {
+	return action == "CAN" || action == "EXP" || action == "UPG"
+}
+
+// actionPriority orders the actions which keep an event going, so the most
+// significant one decides the event's state
+func actionPriority(action string) int {
+	switch action {
+	case "NEW":
+		return 0
+	case "EXT", "EXA", "EXB":
+		return 1
+	case "COR":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// apply runs one event's P-VTEC codes from a product through the state
+// machine. Must hold et.mu.
+func (et *EventTracker) apply(codes []nwwsio.VTEC, received time.Time) []EventUpdate {
+	// The most significant continuing code decides the event's new state,
+	// or the first code when every one of them ends the event
+	primary := -1
+	for i, vtec := range codes {
+		if isTerminalAction(vtec.Action) {
+			continue
+		}
+		if primary < 0 || actionPriority(vtec.Action) < actionPriority(codes[primary].Action) {
+			primary = i
+		}
+	}
+	retire := primary < 0
+	if retire {
+		primary = 0
+	}
+
+	event, previous := et.lookup(codes[primary], received)
+
+	if codes[primary].HasBegin() {
+		event.Begin = codes[primary].Begin
+	}
+	// Codes ending the event for some areas don't change when it ends for
+	// the rest
+	var end time.Time
+	for _, vtec := range codes {
+		if (retire || !isTerminalAction(vtec.Action)) && vtec.End.After(end) {
+			end = vtec.End
+		}
+	}
+	if !end.IsZero() {
+		event.End = end
+	}
+	event.LastAction = codes[primary].Action
+	event.Updated = received
+	event.ProductCount++
+	if previous == nil {
+		event.Issued = received
+	}
+
+	if retire {
+		delete(et.events, event.Key())
+	} else {
+		et.events[event.Key()] = &event
+	}
+
+	updates := make([]EventUpdate, 0, len(codes))
+	for _, vtec := range codes {
+		update := EventUpdate{VTEC: vtec, Event: event, Transition: eventTransition(vtec, previous)}
+		if previous != nil {
+			prev := *previous
+			update.Previous = &prev
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// eventTransition maps a P-VTEC action to the change it made to an event
+func eventTransition(vtec nwwsio.VTEC, previous *ActiveEvent) EventTransition {
+	switch vtec.Action {
+	case "NEW":
+		// A NEW for an event we already know about is a retransmission
+		if previous != nil {
+			return EventContinued
+		}
+		return EventNew
+	case "EXT":
+		// An EXT which doesn't change the end time extends nothing
+		if previous != nil && vtec.End.Equal(previous.End) {
+			return EventContinued
+		}
+		return EventExtended
+	case "EXA", "EXB":
+		return EventExtended
+	case "UPG":
+		return EventUpgraded
+	case "CAN":
+		return EventCancelled
+	case "EXP":
+		return EventExpired
+	case "COR":
+		return EventCorrected
+	default:
+		return EventContinued
+	}
+}
+
+// lookup returns the event a P-VTEC code refers to and its current state, if
+// known. Events are keyed by the year they were issued, so for continuations
+// early in January the previous year is also checked. Must hold et.mu.
+func (et *EventTracker) lookup(vtec nwwsio.VTEC, received time.Time) (ActiveEvent, *ActiveEvent) {
+	year := received.UTC().Year()
+	if vtec.HasBegin() && vtec.Action == "NEW" {
+		year = vtec.Begin.Year()
+	}
+
+	for _, candidate := range []int{year, year - 1} {
+		key := EventKey(vtec.Office, vtec.Phenomena, vtec.Significance, vtec.ETN, candidate)
+		if existing, found := et.events[key]; found {
+			return *existing, existing
+		}
+		if vtec.Action == "NEW" {
+			break
+		}
+	}
+
+	return ActiveEvent{
+		Office:       vtec.Office,
+		Phenomena:    vtec.Phenomena,
+		Significance: vtec.Significance,
+		ETN:          vtec.ETN,
+		Year:         year,
+	}, nil
+}
+
+// String returns a short human-readable summary of the update
+func (u *EventUpdate) String() string {
+	name := fmt.Sprintf("%s from %s", u.Event.EventName(), u.Event.Office)
+
+	switch u.Transition {
+	case EventNew:
+		if u.Event.End.IsZero() {
+			return fmt.Sprintf("NEW: %s", name)
+		}
+		return fmt.Sprintf("NEW: %s until %s", name, formatEventTime(u.Event.End))
+	case EventExtended:
+		// EXA adds areas, EXT moves the end time and EXB does both
+		switch {
+		case u.VTEC.Action == "EXA":
+			return fmt.Sprintf("EXTENDED: %s (area expanded)", name)
+		case u.Event.End.IsZero():
+			return fmt.Sprintf("EXTENDED: %s", name)
+		case u.VTEC.Action == "EXB":
+			return fmt.Sprintf("EXTENDED: %s (area expanded) now until %s", name, formatEventTime(u.Event.End))
+		}
+		return fmt.Sprintf("EXTENDED: %s now until %s", name, formatEventTime(u.Event.End))
+	case EventUpgraded:
+		if u.UpgradedTo != nil {
+			return fmt.Sprintf("UPGRADED: %s upgraded to %s", name, u.UpgradedTo.GetEventName())
+		}
+		return fmt.Sprintf("UPGRADED: %s", name)
+	case EventCancelled:
+		return fmt.Sprintf("CANCELLED: %s", name)
+	case EventExpired:
+		return fmt.Sprintf("EXPIRED: %s", name)
+	case EventCorrected:
+		return fmt.Sprintf("CORRECTED: %s", name)
+	default:
+		if u.Event.End.IsZero() {
+			return fmt.Sprintf("CONTINUES: %s", name)
+		}
+		return fmt.Sprintf("CONTINUES: %s until %s", name, formatEventTime(u.Event.End))
+	}
+}
+
+func formatEventTime(t time.Time) string {
+	return t.UTC().Format("Jan 2 15:04Z")
+}
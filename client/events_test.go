@@ -0,0 +1,285 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// vtecCodes parses P-VTEC strings, failing the test on any error
+func vtecCodes(t *testing.T, raws ...string) []nwwsio.VTEC {
+	t.Helper()
+	var codes []nwwsio.VTEC
+	for _, raw := range raws {
+		vtec, err := nwwsio.ParseVTEC(raw)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", raw, err)
+		}
+		codes = append(codes, *vtec)
+	}
+	return codes
+}
+
+func TestEventTrackerApplyProduct(t *testing.T) {
+	received := time.Date(2026, 5, 6, 22, 0, 0, 0, time.UTC)
+
+	type product struct {
+		codes       []string
+		received    time.Time
+		transitions []EventTransition
+		previous    []bool // whether each update knew the event
+		summary     []string
+	}
+	tests := []struct {
+		name     string
+		products []product
+		active   int
+	}{
+		{
+			name: "NEW then CON",
+			products: []product{
+				{
+					codes:       []string{"/O.NEW.KOUN.SV.W.0101.260506T2200Z-260506T2245Z/"},
+					transitions: []EventTransition{EventNew},
+					previous:    []bool{false},
+				},
+				{
+					codes:       []string{"/O.CON.KOUN.SV.W.0101.000000T0000Z-260506T2245Z/"},
+					transitions: []EventTransition{EventContinued},
+					previous:    []bool{true},
+					summary:     []string{"CONTINUES: Severe Thunderstorm Warning #101 from KOUN until May 6 22:45Z"},
+				},
+			},
+			active: 1,
+		},
+		{
+			name: "NEW then EXT and CAN for some zones then CON",
+			products: []product{
+				{
+					codes:       []string{"/O.NEW.KDTX.WW.Y.0003.260506T2200Z-260507T1200Z/"},
+					transitions: []EventTransition{EventNew},
+					previous:    []bool{false},
+				},
+				{
+					// Repeated across segments, each action is reported once
+					codes: []string{
+						"/O.EXT.KDTX.WW.Y.0003.000000T0000Z-260507T1800Z/",
+						"/O.EXT.KDTX.WW.Y.0003.000000T0000Z-260507T1800Z/",
+						"/O.CAN.KDTX.WW.Y.0003.000000T0000Z-260507T1200Z/",
+					},
+					transitions: []EventTransition{EventExtended, EventCancelled},
+					previous:    []bool{true, true},
+					summary: []string{
+						"EXTENDED: Winter Weather Advisory #3 from KDTX now until May 7 18:00Z",
+						"CANCELLED: Winter Weather Advisory #3 from KDTX",
+					},
+				},
+				{
+					codes:       []string{"/O.CON.KDTX.WW.Y.0003.000000T0000Z-260507T1800Z/"},
+					transitions: []EventTransition{EventContinued},
+					previous:    []bool{true},
+				},
+			},
+			active: 1,
+		},
+		{
+			name: "EXT without a new end time",
+			products: []product{
+				{codes: []string{"/O.NEW.KDTX.WW.Y.0004.260506T2200Z-260507T1200Z/"}, transitions: []EventTransition{EventNew}, previous: []bool{false}},
+				{
+					codes:       []string{"/O.EXT.KDTX.WW.Y.0004.000000T0000Z-260507T1200Z/"},
+					transitions: []EventTransition{EventContinued},
+					previous:    []bool{true},
+				},
+				{
+					codes:       []string{"/O.EXA.KDTX.WW.Y.0004.000000T0000Z-260507T1200Z/"},
+					transitions: []EventTransition{EventExtended},
+					previous:    []bool{true},
+					summary:     []string{"EXTENDED: Winter Weather Advisory #4 from KDTX (area expanded)"},
+				},
+			},
+			active: 1,
+		},
+		{
+			name: "CAN for every zone",
+			products: []product{
+				{codes: []string{"/O.NEW.KDTX.WW.Y.0005.260506T2200Z-260507T1200Z/"}, transitions: []EventTransition{EventNew}, previous: []bool{false}},
+				{
+					codes: []string{
+						"/O.CAN.KDTX.WW.Y.0005.000000T0000Z-260507T1200Z/",
+						"/O.EXP.KDTX.WW.Y.0005.000000T0000Z-260507T1200Z/",
+					},
+					transitions: []EventTransition{EventCancelled, EventExpired},
+					previous:    []bool{true, true},
+				},
+			},
+			active: 0,
+		},
+		{
+			name: "UPG with NEW",
+			products: []product{
+				{codes: []string{"/O.NEW.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/"}, transitions: []EventTransition{EventNew}, previous: []bool{false}},
+				{
+					codes: []string{
+						"/O.UPG.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/",
+						"/O.NEW.KDTX.WS.W.0001.260507T0600Z-260508T0000Z/",
+					},
+					transitions: []EventTransition{EventUpgraded, EventNew},
+					previous:    []bool{true, false},
+					summary: []string{
+						"UPGRADED: Winter Storm Watch #2 from KDTX upgraded to Winter Storm Warning",
+						"NEW: Winter Storm Warning #1 from KDTX until May 8 00:00Z",
+					},
+				},
+			},
+			active: 1,
+		},
+		{
+			name: "continuation after the new year",
+			products: []product{
+				{
+					codes:       []string{"/O.NEW.KDTX.WS.W.0020.251231T2000Z-260101T1200Z/"},
+					received:    time.Date(2025, 12, 31, 20, 0, 0, 0, time.UTC),
+					transitions: []EventTransition{EventNew},
+					previous:    []bool{false},
+				},
+				{
+					codes:       []string{"/O.CON.KDTX.WS.W.0020.000000T0000Z-260101T1200Z/"},
+					received:    time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC),
+					transitions: []EventTransition{EventContinued},
+					previous:    []bool{true},
+				},
+				{
+					// A NEW in the new year is a different event with the same ETN
+					codes:       []string{"/O.NEW.KDTX.WS.W.0020.260102T2000Z-260103T1200Z/"},
+					received:    time.Date(2026, 1, 2, 20, 0, 0, 0, time.UTC),
+					transitions: []EventTransition{EventNew},
+					previous:    []bool{false},
+				},
+			},
+			active: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := NewEventTracker()
+			for i, p := range test.products {
+				at := p.received
+				if at.IsZero() {
+					at = received.Add(time.Duration(i) * time.Minute)
+				}
+
+				updates := tracker.ApplyProduct(vtecCodes(t, p.codes...), at)
+				if len(updates) != len(p.transitions) {
+					t.Fatalf("product %d: got %d updates, want %d", i, len(updates), len(p.transitions))
+				}
+				for j, update := range updates {
+					if update.Transition != p.transitions[j] {
+						t.Errorf("product %d update %d: got %s, want %s", i, j, update.Transition, p.transitions[j])
+					}
+					if (update.Previous != nil) != p.previous[j] {
+						t.Errorf("product %d update %d: got previous %v, want %v", i, j, update.Previous != nil, p.previous[j])
+					}
+					if j < len(p.summary) && update.String() != p.summary[j] {
+						t.Errorf("product %d update %d: got %q, want %q", i, j, update.String(), p.summary[j])
+					}
+				}
+			}
+
+			if active := tracker.GetActiveEvents(""); len(active) != test.active {
+				t.Errorf("got %d active events, want %d", len(active), test.active)
+			}
+		})
+	}
+}
+
+func TestEventTrackerLinksUpgrades(t *testing.T) {
+	received := time.Date(2026, 5, 6, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		segments [][]string
+		want     string // the event the watch was upgraded to, empty when unlinked
+	}{
+		{
+			name: "UPG with NEW",
+			segments: [][]string{{
+				"/O.UPG.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/",
+				"/O.NEW.KDTX.WS.W.0001.260507T0600Z-260508T0000Z/",
+			}},
+			want: "Winter Storm Warning #1 from KDTX",
+		},
+		{
+			name: "two NEW codes in one segment",
+			segments: [][]string{{
+				"/O.NEW.KDTX.WW.Y.0005.260507T0600Z-260508T0000Z/",
+				"/O.UPG.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/",
+				"/O.NEW.KDTX.WS.W.0001.260507T0600Z-260508T0000Z/",
+			}},
+		},
+		{
+			name: "unrelated NEW in another segment",
+			segments: [][]string{
+				{"/O.NEW.KDTX.WW.Y.0005.260507T0600Z-260508T0000Z/"},
+				{
+					"/O.UPG.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/",
+					"/O.NEW.KDTX.WS.W.0001.260507T0600Z-260508T0000Z/",
+				},
+				{
+					"/O.UPG.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/",
+					"/O.NEW.KDTX.WS.W.0001.260507T0600Z-260508T0000Z/",
+				},
+			},
+			want: "Winter Storm Warning #1 from KDTX",
+		},
+		{
+			name: "NEW from another office",
+			segments: [][]string{{
+				"/O.UPG.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/",
+				"/O.NEW.KGRR.WS.W.0003.260507T0600Z-260508T0000Z/",
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := NewEventTracker()
+			tracker.ApplyProduct(vtecCodes(t, "/O.NEW.KDTX.WS.A.0002.260507T0600Z-260508T0000Z/"), received)
+
+			var segments [][]nwwsio.VTEC
+			for _, segment := range test.segments {
+				segments = append(segments, vtecCodes(t, segment...))
+			}
+
+			got, upgrades := "", 0
+			for _, update := range tracker.ApplySegments(segments, received.Add(time.Hour)) {
+				if update.Transition != EventUpgraded {
+					continue
+				}
+				upgrades++
+				if update.UpgradedTo != nil {
+					got = update.UpgradedTo.String()
+				}
+			}
+			if upgrades != 1 {
+				t.Fatalf("got %d upgrade updates, want 1", upgrades)
+			}
+			if got != test.want {
+				t.Errorf("got upgraded to %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEventTrackerCloseTwice(t *testing.T) {
+	tracker := NewEventTracker()
+	tracker.Start()
+	if err := tracker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+/*
+Subscription filters come in two kinds:
+
+Selectors choose which products are delivered and any one of them matching is
+enough. These are "all", "cap", "emergency" (PDS, tornado and flash flood
+emergencies), the product categories and:
+
+<PP>.<S>  - a VTEC event with this phenomena and significance, either of which
+            may be * (e.g., TO.W, SV.*, *.A)
+pil:<NNN> - an AWIPS ID starting with this (e.g., pil:AFD, pil:AFDDTX)
+
+Constraints narrow the selected products and every one of them has to match.
+A subscription with only constraints selects every product. Threat tag
+constraints:
+
+tornado:<possible|radar|observed> - tornado tag at least this certain
+waterspout:<possible|radar|observed>
+flood:<possible|radar|observed>   - flash flood tag at least this certain
+hail>=<inches>                    - MAX HAIL SIZE at least this large
+wind>=<mph>                       - MAX WIND GUST at least this strong
+damage>=<considerable|destructive|catastrophic>
+
+CAP constraints compare the alert's severity, urgency or certainty using the
+ordering in nwwsio.SeverityRank and friends. Products without CAP never match.
+
+severity>=<minor|moderate|severe|extreme>
+urgency>=<past|future|expected|immediate>
+certainty>=<unlikely|possible|likely|observed>
+*/
+
+// productConstraint reports whether a product satisfies a constraint filter
+type productConstraint func(info *productInfo) bool
+
+// productSelector reports whether a VTEC or pil selector chooses a product
+type productSelector func(info *productInfo) bool
+
+// detectionFilterNames maps the short names accepted in filters to detection tags
+var detectionFilterNames = map[string]string{
+	"possible": nwwsio.DetectionPossible,
+	"radar":    nwwsio.DetectionRadarIndicated,
+	"observed": nwwsio.DetectionObserved,
+}
+
+// parseConstraint parses a constraint filter. It returns false if the filter
+// isn't a constraint, and an error if it is one but is malformed.
+func parseConstraint(filter string) (productConstraint, bool, error) {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	if name, value, found := strings.Cut(filter, ":"); found {
+		var detection func(tags *nwwsio.ThreatTags) string
+		switch name {
+		case "tornado":
+			detection = func(tags *nwwsio.ThreatTags) string { return tags.Tornado }
+		case "waterspout":
+			detection = func(tags *nwwsio.ThreatTags) string { return tags.Waterspout }
+		case "flood":
+			detection = func(tags *nwwsio.ThreatTags) string { return tags.FlashFlood }
+		default:
+			return nil, false, nil
+		}
+
+		minimum, ok := detectionFilterNames[value]
+		if !ok {
+			return nil, true, fmt.Errorf("%s: expected possible, radar or observed", filter)
+		}
+		return func(info *productInfo) bool {
+			return info.tags != nil && nwwsio.DetectionRank(detection(info.tags)) >= nwwsio.DetectionRank(minimum)
+		}, true, nil
+	}
+
+	name, value, found := strings.Cut(filter, ">=")
+	if !found {
+		return nil, false, nil
+	}
+
+	switch name {
+	case "hail":
+		minimum, err := strconv.ParseFloat(value, 64)
+		if err != nil || minimum <= 0 {
+			return nil, true, fmt.Errorf("%s: expected a hail size in inches", filter)
+		}
+		return func(info *productInfo) bool {
+			return info.tags != nil && info.tags.MaxHailSize >= minimum
+		}, true, nil
+
+	case "wind":
+		minimum, err := strconv.Atoi(value)
+		if err != nil || minimum <= 0 {
+			return nil, true, fmt.Errorf("%s: expected a wind gust in mph", filter)
+		}
+		return func(info *productInfo) bool {
+			return info.tags != nil && info.tags.MaxWindGust >= minimum
+		}, true, nil
+
+	case "damage":
+		minimum := nwwsio.DamageThreatRank(value)
+		if minimum == 0 {
+			return nil, true, fmt.Errorf("%s: expected considerable, destructive or catastrophic", filter)
+		}
+		return func(info *productInfo) bool {
+			return info.tags != nil && nwwsio.DamageThreatRank(info.tags.MaxDamageThreat()) >= minimum
+		}, true, nil
+
+	case "severity":
+		minimum := nwwsio.SeverityRank(value)
+		if minimum == 0 {
+			return nil, true, fmt.Errorf("%s: expected minor, moderate, severe or extreme", filter)
+		}
+		return capConstraint(func(capInfo *nwwsio.Info) bool {
+			return nwwsio.SeverityRank(capInfo.Severity) >= minimum
+		}), true, nil
+
+	case "urgency":
+		minimum := nwwsio.UrgencyRank(value)
+		if minimum == 0 {
+			return nil, true, fmt.Errorf("%s: expected past, future, expected or immediate", filter)
+		}
+		return capConstraint(func(capInfo *nwwsio.Info) bool {
+			return nwwsio.UrgencyRank(capInfo.Urgency) >= minimum
+		}), true, nil
+
+	case "certainty":
+		minimum := nwwsio.CertaintyRank(value)
+		if minimum == 0 {
+			return nil, true, fmt.Errorf("%s: expected unlikely, possible, likely or observed", filter)
+		}
+		return capConstraint(func(capInfo *nwwsio.Info) bool {
+			return nwwsio.CertaintyRank(capInfo.Certainty) >= minimum
+		}), true, nil
+	}
+	return nil, false, nil
+}
+
+// describeConstraint returns a readable description of a constraint filter
+// for subscription confirmations (e.g., "with CAP severity Severe or higher"
+// or "matching hail>=1.5")
+func describeConstraint(filter string) string {
+	name, value, found := strings.Cut(strings.ToLower(strings.TrimSpace(filter)), ">=")
+	if found && value != "" {
+		switch name {
+		case "severity", "urgency", "certainty":
+			return fmt.Sprintf("with CAP %s %s or higher", name, strings.ToUpper(value[:1])+value[1:])
+		}
+	}
+	return "matching " + filter
+}
+
+// capConstraint wraps a check of a product's primary CAP info, failing
+// products without CAP
+func capConstraint(check func(capInfo *nwwsio.Info) bool) productConstraint {
+	return func(info *productInfo) bool {
+		if info.capAlert == nil || info.capAlert.GetPrimaryInfo() == nil {
+			return false
+		}
+		return check(info.capAlert.GetPrimaryInfo())
+	}
+}
+
+// parseSelector parses a VTEC or pil selector. It returns false if the
+// filter isn't one, and an error if it is one but is malformed.
+func parseSelector(filter string) (productSelector, bool, error) {
+	filter = strings.ToUpper(strings.TrimSpace(filter))
+
+	if pil, found := strings.CutPrefix(filter, "PIL:"); found {
+		if len(pil) < 3 || len(pil) > 6 || !isAlphanumeric(pil) {
+			return nil, true, fmt.Errorf("%s: expected a 3 to 6 character AWIPS ID", filter)
+		}
+		return func(info *productInfo) bool {
+			return strings.HasPrefix(info.awipsID, pil)
+		}, true, nil
+	}
+
+	phenomena, significance, found := strings.Cut(filter, ".")
+	if !found {
+		return nil, false, nil
+	}
+	if _, known := nwwsio.VTECPhenomena[phenomena]; !known && phenomena != "*" {
+		return nil, true, fmt.Errorf("%s: unknown VTEC phenomena %s", filter, phenomena)
+	}
+	if _, known := nwwsio.VTECSignificance[significance]; !known && significance != "*" {
+		return nil, true, fmt.Errorf("%s: unknown VTEC significance %s", filter, significance)
+	}
+	return func(info *productInfo) bool {
+		for _, vtec := range info.vtec {
+			if (phenomena == "*" || vtec.Phenomena == phenomena) && (significance == "*" || vtec.Significance == significance) {
+				return true
+			}
+		}
+		return false
+	}, true, nil
+}
+
+// describeSelector returns a readable description of a VTEC or pil selector
+// (e.g., "VTEC Tornado Warning" or "AWIPS ID AFD*"), or false if the filter
+// isn't a valid one
+func describeSelector(filter string) (string, bool) {
+	if _, ok, err := parseSelector(filter); !ok || err != nil {
+		return "", false
+	}
+
+	filter = strings.ToUpper(strings.TrimSpace(filter))
+	if pil, found := strings.CutPrefix(filter, "PIL:"); found {
+		return fmt.Sprintf("AWIPS ID %s*", pil), true
+	}
+
+	phenomena, significance, _ := strings.Cut(filter, ".")
+	switch {
+	case phenomena == "*" && significance == "*":
+		return "any VTEC event", true
+	case phenomena == "*":
+		return fmt.Sprintf("any VTEC %s", nwwsio.VTECSignificance[significance]), true
+	case significance == "*":
+		return fmt.Sprintf("any VTEC %s event", nwwsio.VTECPhenomena[phenomena]), true
+	}
+	return fmt.Sprintf("VTEC %s %s", nwwsio.VTECPhenomena[phenomena], nwwsio.VTECSignificance[significance]), true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesSelector reports whether a selector filter chooses the product
+func matchesSelector(filter string, info *productInfo) bool {
+	switch filter {
+	case "all":
+		return true
+	case "cap":
+		return info.capAlert != nil
+	case "emergency":
+		return len(info.escalations) > 0
+	}
+	if selector, ok, err := parseSelector(filter); ok {
+		return err == nil && selector(info)
+	}
+	return filter == strings.ToLower(info.productCategory)
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// taggedTornadoWarning is tornadoWarning with threat tags after the polygon
+func taggedTornadoWarning(id, tags string) fakeProduct {
+	product := tornadoWarning(id)
+	product.Text = strings.Replace(product.Text, "\n\n$$\n", "\n"+tags+"\n\n$$\n", 1)
+	return product
+}
+
+func TestShouldSendToSubscriber(t *testing.T) {
+	observed := taggedTornadoWarning("5001.1", "TIME...MOT...LOC 2214Z 240DEG 30KT 3520 9740\n\n"+
+		"TORNADO...OBSERVED\nTORNADO DAMAGE THREAT...CONSIDERABLE\nMAX HAIL SIZE...1.50 IN\n")
+	radar := taggedTornadoWarning("5001.2", "TORNADO...RADAR INDICATED\nMAX HAIL SIZE...1.00 IN\n")
+	untagged := tornadoWarning("5001.3")
+
+	tests := []struct {
+		filters []string
+		want    []bool // observed, radar, untagged
+	}{
+		{[]string{"warning"}, []bool{true, true, true}},
+		{[]string{"tornado:observed"}, []bool{true, false, false}},
+		{[]string{"tornado:radar"}, []bool{true, true, false}},
+		{[]string{"hail>=1.5"}, []bool{true, false, false}},
+		{[]string{"hail>=1"}, []bool{true, true, false}},
+		{[]string{"damage>=considerable"}, []bool{true, false, false}},
+		{[]string{"damage>=catastrophic"}, []bool{false, false, false}},
+		{[]string{"wind>=60"}, []bool{false, false, false}},
+		{[]string{"watch", "hail>=1"}, []bool{false, false, false}},
+		{[]string{"watch", "warning", "hail>=1"}, []bool{true, true, false}},
+		{[]string{"warning", "tornado:radar", "hail>=1.5"}, []bool{true, false, false}},
+		{[]string{"cap", "tornado:observed"}, []bool{false, false, false}},
+		{[]string{"to.w"}, []bool{true, true, true}},
+		{[]string{"TO.*", "hail>=1.5"}, []bool{true, false, false}},
+		{[]string{"*.w"}, []bool{true, true, true}},
+		{[]string{"sv.w", "*.a"}, []bool{false, false, false}},
+		{[]string{"pil:tor"}, []bool{true, true, true}},
+		{[]string{"pil:TOROUN"}, []bool{true, true, true}},
+		{[]string{"pil:afd", "pil:torjan"}, []bool{false, false, false}},
+	}
+
+	for _, test := range tests {
+		for i, product := range []fakeProduct{observed, radar, untagged} {
+			info, err := parseProductInfo(product.extension())
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", product.ID, err)
+			}
+			sub := Subscription{Subscriber: Subscriber{UserID: testUser}, Filters: test.filters}
+			if got := shouldSendToSubscriber(sub, info); got != test.want[i] {
+				t.Errorf("filters %v on product %s: got %v, want %v", test.filters, product.ID, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	valid := []string{"tornado:observed", "waterspout:possible", "flood:radar", "hail>=1.75", "wind>=58", "damage>=destructive", "WARNING", "Hail>=2",
+		"TO.W", "sv.*", "*.A", "*.*", "pil:AFD", "pil:afddtx", "severity>=severe", "Urgency>=Immediate", "certainty>=likely"}
+	if invalid := ValidateFilters(valid); len(invalid) != 0 {
+		t.Errorf("valid filters rejected: %v", invalid)
+	}
+
+	invalid := []string{"tornado:maybe", "hail>=big", "wind>=-5", "damage>=some", "hail<=2", "XX.W", "TO.Q", "TO.", "pil:", "pil:AF", "pil:AFD.X",
+		"severity>=unknown", "severity>=bad", "urgency>=soon", "certainty>=very"}
+	if got := ValidateFilters(invalid); len(got) != len(invalid) {
+		t.Errorf("invalid filters accepted, only rejected %v", got)
+	}
+}
+
+func TestCAPThresholdFilters(t *testing.T) {
+	capProduct := func(severity, urgency, certainty string) *productInfo {
+		return &productInfo{capAlert: &nwwsio.Alert{Info: []nwwsio.Info{{Severity: severity, Urgency: urgency, Certainty: certainty}}}}
+	}
+	extreme := capProduct("Extreme", "Immediate", "Observed")
+	moderate := capProduct("Moderate", "Expected", "Likely")
+	unknown := capProduct("Unknown", "Unknown", "Unknown")
+	text := &productInfo{productCategory: "Warning"}
+
+	tests := []struct {
+		filters []string
+		want    []bool // extreme, moderate, unknown, text
+	}{
+		{[]string{"severity>=severe"}, []bool{true, false, false, false}},
+		{[]string{"severity>=minor"}, []bool{true, true, false, false}},
+		{[]string{"urgency>=expected"}, []bool{true, true, false, false}},
+		{[]string{"certainty>=observed"}, []bool{true, false, false, false}},
+		{[]string{"severity>=moderate", "urgency>=immediate"}, []bool{true, false, false, false}},
+		{[]string{"warning", "severity>=minor"}, []bool{false, false, false, false}},
+	}
+
+	for _, test := range tests {
+		for i, info := range []*productInfo{extreme, moderate, unknown, text} {
+			sub := Subscription{Subscriber: Subscriber{UserID: testUser}, Filters: test.filters}
+			if got := shouldSendToSubscriber(sub, info); got != test.want[i] {
+				t.Errorf("filters %v on product %d: got %v, want %v", test.filters, i, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestThreatTagsInAlert(t *testing.T) {
+	product := taggedTornadoWarning("5002.1", "TIME...MOT...LOC 2214Z 240DEG 30KT 3520 9740\n\nTORNADO...OBSERVED\nMAX HAIL SIZE...1.50 IN\n")
+	info, err := parseProductInfo(product.extension())
+	if err != nil {
+		t.Fatalf("failed to parse product: %v", err)
+	}
+
+	want := "Tornado observed | Hail 1.50 in | Storm moving ENE at 35 mph"
+	if alert := formatAlertMessage(product.extension(), info); !strings.Contains(alert, "\n"+want+"\n") {
+		t.Errorf("alert is missing %q:\n%s", want, alert)
+	}
+}
+
+func TestEmergencyFilter(t *testing.T) {
+	emergency := taggedTornadoWarning("5003.1", "TORNADO...OBSERVED\nTORNADO DAMAGE THREAT...CATASTROPHIC\n")
+	pds := tornadoWarning("5003.2")
+	pds.Text = strings.Replace(pds.Text, "has issued a\n", "has issued a\n\nThis is a PARTICULARLY DANGEROUS\nSITUATION. TAKE COVER NOW!\n", 1)
+	routine := tornadoWarning("5003.3")
+
+	// Discussions recalling an emergency aren't escalations
+	discussion := tornadoWarning("5003.4")
+	discussion.Ttaaii = "FXUS64"
+	discussion.AwipsID = "AFDOUN"
+	discussion.Text = "FXUS64 KOUN 062214\nAFDOUN\n\nThe tornado emergency yesterday...\n"
+
+	sub := Subscription{Subscriber: Subscriber{UserID: testUser}, Filters: []string{"emergency"}}
+	for _, test := range []struct {
+		product fakeProduct
+		banner  string
+	}{
+		{emergency, "!!! TORNADO EMERGENCY !!!\n"},
+		{pds, "!!! PARTICULARLY DANGEROUS SITUATION !!!\n"},
+		{routine, ""},
+		{discussion, ""},
+	} {
+		info, err := parseProductInfo(test.product.extension())
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", test.product.ID, err)
+		}
+		if got := shouldSendToSubscriber(sub, info); got != (test.banner != "") {
+			t.Errorf("emergency filter on product %s: got %v", test.product.ID, got)
+		}
+
+		alert := formatAlertMessage(test.product.extension(), info)
+		if test.banner != "" && !strings.HasPrefix(alert, test.banner) {
+			t.Errorf("alert for product %s doesn't start with %q:\n%s", test.product.ID, test.banner, alert)
+		}
+		if test.banner == "" && strings.Contains(alert, "!!!") {
+			t.Errorf("alert for product %s has a banner:\n%s", test.product.ID, alert)
+		}
+	}
+}
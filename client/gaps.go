@@ -0,0 +1,387 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Number of gaps kept for reporting, older gaps are dropped
+	MaxTrackedGaps = 100
+	// Number of gaps listed by the gaps command
+	MaxGapsShown = 10
+	// A sequence number this far behind the last one is treated as the
+	// ingest process resetting its counter rather than a late arrival
+	SequenceResetThreshold = 1000
+	// Processes not heard from in this long are forgotten
+	GapProcessIdleAge = 6 * time.Hour
+)
+
+// SequenceGap is a range of sequence numbers that were skipped by an NWWS-OI
+// ingest process, along with the window in which they should have arrived
+type SequenceGap struct {
+	Site      string
+	ProcessID string
+	First     int       // first missing sequence number
+	Last      int       // last missing sequence number
+	After     time.Time // when the product before the gap was received
+	Before    time.Time // when the product after the gap was received
+	Recovered int       // missing products that later arrived out of order
+
+	recovered map[int]bool // sequence numbers that arrived late
+}
+
+// Size returns the number of sequence numbers in the gap
+func (g *SequenceGap) Size() int {
+	return g.Last - g.First + 1
+}
+
+// Missing returns the number of sequence numbers still not received
+func (g *SequenceGap) Missing() int {
+	return g.Size() - g.Recovered
+}
+
+// String returns a short description such as "12345.100-105 (6 missed, 15:04:05-15:04:30Z)"
+func (g *SequenceGap) String() string {
+	span := fmt.Sprintf("%s.%d", g.ProcessID, g.First)
+	if g.Last != g.First {
+		span += fmt.Sprintf("-%d", g.Last)
+	}
+	return fmt.Sprintf("%s (%d missed, %s-%sZ)",
+		span,
+		g.Missing(),
+		g.After.UTC().Format("15:04:05"),
+		g.Before.UTC().Format("15:04:05"))
+}
+
+// snapshot returns a copy that is safe to use without holding the tracker lock
+func (g *SequenceGap) snapshot() SequenceGap {
+	copied := *g
+	copied.recovered = nil
+	return copied
+}
+
+// GapObservation describes what a single sequence number revealed
+type GapObservation struct {
+	Gap       *SequenceGap // newly detected gap, nil if none
+	Restart   bool         // a new ingest process or a counter reset
+	Late      bool         // the product filled part of an earlier gap
+	Duplicate bool         // the sequence number was already seen
+}
+
+// GapStats is a snapshot of the running gap counters
+type GapStats struct {
+	Gaps       int // gaps detected
+	Missed     int // sequence numbers still missing
+	Late       int // products that arrived after being counted missing
+	Duplicates int
+	Restarts   int
+}
+
+// gapNotice is a subscriber told they may have missed products during an event
+type gapNotice struct {
+	event      string
+	subscriber Subscriber
+}
+
+type sequenceState struct {
+	site     string
+	last     int
+	lastSeen time.Time
+}
+
+// GapTracker follows the processID.sequenceID numbering of NWWS-OI products
+// to detect products that were never received. Each site has its own ingest
+// process, so processes are tracked per site.
+type GapTracker struct {
+	mu        sync.Mutex
+	processes map[string]*sequenceState // site and process ID -> last sequence seen
+	gaps      []*SequenceGap            // oldest first
+	stats     GapStats
+	notices   map[gapNotice]bool
+}
+
+func NewGapTracker() *GapTracker {
+	return &GapTracker{
+		processes: make(map[string]*sequenceState),
+		notices:   make(map[gapNotice]bool),
+	}
+}
+
+// Observe records a received sequence number for a site's process
+func (gt *GapTracker) Observe(site, processID string, sequenceID int, received time.Time) GapObservation {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+
+	var obs GapObservation
+
+	key := site + "/" + processID
+	state, exists := gt.processes[key]
+	if !exists {
+		gt.pruneProcesses(received)
+
+		// A new process ID on a site already sending products means its
+		// ingest process restarted, numbering starts over so there is
+		// nothing to compare against
+		if gt.hasSite(site) {
+			obs.Restart = true
+			gt.stats.Restarts++
+		}
+		gt.processes[key] = &sequenceState{site: site, last: sequenceID, lastSeen: received}
+		return obs
+	}
+
+	switch {
+	case sequenceID == state.last+1:
+		state.last = sequenceID
+		state.lastSeen = received
+
+	case sequenceID > state.last+1:
+		gap := &SequenceGap{
+			Site:      site,
+			ProcessID: processID,
+			First:     state.last + 1,
+			Last:      sequenceID - 1,
+			After:     state.lastSeen,
+			Before:    received,
+			recovered: make(map[int]bool),
+		}
+		gt.gaps = append(gt.gaps, gap)
+		if len(gt.gaps) > MaxTrackedGaps {
+			gt.gaps = gt.gaps[1:]
+		}
+		gt.stats.Gaps++
+		gt.stats.Missed += gap.Size()
+
+		state.last = sequenceID
+		state.lastSeen = received
+		copied := gap.snapshot()
+		obs.Gap = &copied
+
+	case state.last-sequenceID > SequenceResetThreshold:
+		obs.Restart = true
+		gt.stats.Restarts++
+		state.last = sequenceID
+		state.lastSeen = received
+
+	default:
+		gap := gt.findGap(site, processID, sequenceID)
+		if gap == nil || gap.recovered[sequenceID] {
+			obs.Duplicate = true
+			gt.stats.Duplicates++
+			break
+		}
+		gap.recovered[sequenceID] = true
+		gap.Recovered++
+		obs.Late = true
+		gt.stats.Late++
+		gt.stats.Missed--
+	}
+
+	return obs
+}
+
+// hasSite reports whether any process is tracked for a site. Must hold gt.mu.
+func (gt *GapTracker) hasSite(site string) bool {
+	for _, state := range gt.processes {
+		if state.site == site {
+			return true
+		}
+	}
+	return false
+}
+
+// findGap returns the tracked gap containing a sequence number. Must hold gt.mu.
+func (gt *GapTracker) findGap(site, processID string, sequenceID int) *SequenceGap {
+	for _, gap := range gt.gaps {
+		if gap.Site == site && gap.ProcessID == processID && sequenceID >= gap.First && sequenceID <= gap.Last {
+			return gap
+		}
+	}
+	return nil
+}
+
+// pruneProcesses forgets processes that have gone quiet. Must hold gt.mu.
+func (gt *GapTracker) pruneProcesses(now time.Time) {
+	for key, state := range gt.processes {
+		if now.Sub(state.lastSeen) > GapProcessIdleAge {
+			delete(gt.processes, key)
+		}
+	}
+}
+
+// Stats returns the running gap counters
+func (gt *GapTracker) Stats() GapStats {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+
+	return gt.stats
+}
+
+// markNotified records that a subscriber was told about possible missed
+// products during an event, returning false if they already were
+func (gt *GapTracker) markNotified(event string, subscriber Subscriber) bool {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+
+	notice := gapNotice{event: event, subscriber: subscriber}
+	if gt.notices[notice] {
+		return false
+	}
+	gt.notices[notice] = true
+	return true
+}
+
+// pruneNotices forgets the notices for events that are no longer active
+func (gt *GapTracker) pruneNotices(active map[string]bool) {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+
+	for notice := range gt.notices {
+		if !active[notice.event] {
+			delete(gt.notices, notice)
+		}
+	}
+}
+
+// RecentGaps returns up to limit gaps that still have missing products, newest first
+func (gt *GapTracker) RecentGaps(limit int) []SequenceGap {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+
+	var result []SequenceGap
+	for i := len(gt.gaps) - 1; i >= 0 && len(result) < limit; i-- {
+		if gt.gaps[i].Missing() > 0 {
+			result = append(result, gt.gaps[i].snapshot())
+		}
+	}
+	return result
+}
+
+// checkSequenceGaps records the sequence number of a received product, updates
+// metrics and warns subscribers when products may have been missed
+func checkSequenceGaps(client *SeabirdClient, site, processID string, sequenceID int, received time.Time) {
+	obs := client.gaps.Observe(site, processID, sequenceID, received)
+	stats := client.gaps.Stats()
+	metricSequenceGaps.Set(int64(stats.Gaps))
+	metricMissedProducts.Set(int64(stats.Missed))
+	metricLateProducts.Set(int64(stats.Late))
+	metricProcessRestarts.Set(int64(stats.Restarts))
+
+	switch {
+	case obs.Restart:
+		log.Info().
+			Str("process_id", processID).
+			Int("received_seq", sequenceID).
+			Msg("NWWS-OI sequence restarted - new ingest process")
+	case obs.Late:
+		log.Info().
+			Str("process_id", processID).
+			Int("received_seq", sequenceID).
+			Msg("Received late product from an earlier sequence gap")
+	case obs.Duplicate:
+		log.Debug().
+			Str("process_id", processID).
+			Int("received_seq", sequenceID).
+			Msg("Received duplicate sequence number")
+	case obs.Gap != nil:
+		log.Warn().
+			Str("site", site).
+			Str("process_id", processID).
+			Int("first_missed_seq", obs.Gap.First).
+			Int("last_missed_seq", obs.Gap.Last).
+			Int("missed_count", obs.Gap.Size()).
+			Time("after", obs.Gap.After).
+			Time("before", obs.Gap.Before).
+			Msg("Detected missed messages - sequence gap")
+		// Another site may have delivered the products this one missed
+		if client.dedup != nil && client.dedup.OtherSiteActive(site, obs.Gap.After) {
+			return
+		}
+		client.notifyPossibleMissedProducts(obs.Gap)
+	}
+}
+
+// notifyPossibleMissedProducts tells subscribers that products may have been
+// missed while an event they would hear about was active. Station subscribers
+// hear about their office's events, and area, SAME and point subscribers about
+// events covering them, as long as their filters accept the latest product
+// about the event. Subscribers are only told once per event.
+func (c *SeabirdClient) notifyPossibleMissedProducts(gap *SequenceGap) {
+	events := c.events.GetActiveEvents("")
+	active := make(map[string]bool)
+	for _, event := range events {
+		active[event.Key()] = true
+	}
+	c.gaps.pruneNotices(active)
+	if len(events) == 0 {
+		return
+	}
+
+	// Each subscriber gets one message listing the events by office
+	notices := make(map[Subscriber]map[string][]string) // subscriber -> office -> event names
+	add := func(subscriptions []Subscription, event ActiveEvent, info *productInfo) {
+		for _, sub := range subscriptions {
+			if !shouldSendToSubscriber(sub, info) || !c.gaps.markNotified(event.Key(), sub.Subscriber) {
+				continue
+			}
+			offices := notices[sub.Subscriber]
+			if offices == nil {
+				offices = make(map[string][]string)
+				notices[sub.Subscriber] = offices
+			}
+			offices[event.Office] = append(offices[event.Office], event.EventName())
+		}
+	}
+
+	offices := make(map[string]bool)
+	for _, event := range events {
+		if !event.End.IsZero() && event.End.Before(gap.After) {
+			continue
+		}
+		offices[event.Office] = true
+
+		info := event.productInfo()
+		add(c.subscriptions.GetStationSubscriptions(event.Office), event, info)
+		if len(event.UGC) > 0 {
+			add(c.subscriptions.GetAreaSubscriptions(event.UGC), event, info)
+		}
+		if len(event.SAME) > 0 {
+			add(c.subscriptions.GetSAMESubscriptions(event.SAME), event, info)
+		}
+		if len(event.Polygons) > 0 {
+			add(pointSubscriptions(c.subscriptions.GetPointSubscriptions(event.Polygons)), event, info)
+		}
+	}
+
+	for target, byOffice := range notices {
+		var lines []string
+		for office, names := range byOffice {
+			sort.Strings(names)
+			lines = append(lines, fmt.Sprintf("%s (check !noaa recent %s)", strings.Join(names, ", "), office))
+		}
+		sort.Strings(lines)
+		msg := fmt.Sprintf("Possible missed products: NWWS-OI skipped %d product(s) between %s and %s while these events were active:\n%s",
+			gap.Size(),
+			gap.After.UTC().Format("15:04:05Z"),
+			gap.Before.UTC().Format("15:04:05Z"),
+			strings.Join(lines, "\n"))
+		if target.IsChannel() {
+			c.SendMessage(target.ChannelID, msg)
+		} else {
+			c.SendPrivateMessage(target.UserID, msg)
+		}
+	}
+
+	if len(notices) > 0 {
+		log.Info().
+			Int("subscribers", len(notices)).
+			Int("offices", len(offices)).
+			Msg("Notified subscribers of possible missed products")
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestGapTrackerObserve(t *testing.T) {
+	start := time.Date(2026, 5, 6, 22, 0, 0, 0, time.UTC)
+
+	type observation struct {
+		site      string
+		processID string
+		sequence  int
+		gap       string // First-Last of a new gap
+		restart   bool
+		late      bool
+		duplicate bool
+	}
+	tests := []struct {
+		name         string
+		observations []observation
+		want         GapStats
+	}{
+		{
+			name: "gap then late arrival and duplicate",
+			observations: []observation{
+				{site: "a", processID: "100", sequence: 1},
+				{site: "a", processID: "100", sequence: 2},
+				{site: "a", processID: "100", sequence: 5, gap: "3-4"},
+				{site: "a", processID: "100", sequence: 3, late: true},
+				{site: "a", processID: "100", sequence: 3, duplicate: true},
+				{site: "a", processID: "100", sequence: 2, duplicate: true},
+			},
+			want: GapStats{Gaps: 1, Missed: 1, Late: 1, Duplicates: 2},
+		},
+		{
+			name: "second site is not a restart",
+			observations: []observation{
+				{site: "a", processID: "100", sequence: 1},
+				{site: "b", processID: "200", sequence: 50},
+				{site: "a", processID: "100", sequence: 2},
+				{site: "b", processID: "200", sequence: 51},
+			},
+			want: GapStats{},
+		},
+		{
+			name: "same process ID on both sites",
+			observations: []observation{
+				{site: "a", processID: "100", sequence: 1},
+				{site: "b", processID: "100", sequence: 1},
+				{site: "a", processID: "100", sequence: 2},
+				{site: "b", processID: "100", sequence: 3, gap: "2-2"},
+			},
+			want: GapStats{Gaps: 1, Missed: 1},
+		},
+		{
+			name: "new process on a site is a restart",
+			observations: []observation{
+				{site: "a", processID: "100", sequence: 900},
+				{site: "a", processID: "101", sequence: 1, restart: true},
+				{site: "a", processID: "101", sequence: 2},
+			},
+			want: GapStats{Restarts: 1},
+		},
+		{
+			name: "counter reset",
+			observations: []observation{
+				{site: "a", processID: "100", sequence: 5000},
+				{site: "a", processID: "100", sequence: 1, restart: true},
+				{site: "a", processID: "100", sequence: 2},
+			},
+			want: GapStats{Restarts: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := NewGapTracker()
+			for i, o := range test.observations {
+				obs := tracker.Observe(o.site, o.processID, o.sequence, start.Add(time.Duration(i)*time.Second))
+
+				gap := ""
+				if obs.Gap != nil {
+					gap = fmt.Sprintf("%d-%d", obs.Gap.First, obs.Gap.Last)
+					if obs.Gap.Site != o.site {
+						t.Errorf("observation %d: gap is for site %s", i, obs.Gap.Site)
+					}
+				}
+				if gap != o.gap || obs.Restart != o.restart || obs.Late != o.late || obs.Duplicate != o.duplicate {
+					t.Errorf("observation %d (%s %s.%d): got gap %q restart %v late %v duplicate %v",
+						i, o.site, o.processID, o.sequence, gap, obs.Restart, obs.Late, obs.Duplicate)
+				}
+			}
+			if stats := tracker.Stats(); stats != test.want {
+				t.Errorf("got stats %+v, want %+v", stats, test.want)
+			}
+		})
+	}
+}
+
+func TestNotifyPossibleMissedProducts(t *testing.T) {
+	client, core := startCommandClient(t, Config{})
+
+	// An event with no end so it is active whatever the time
+	received := time.Now().UTC()
+	codes := vtecCodes(t, "/O.NEW.KOUN.TO.W.0042.000000T0000Z-000000T0000Z/")
+	updates := client.events.ApplyProduct(codes, received)
+	if len(updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(updates))
+	}
+	client.events.setProduct(updates[0].Event.Key(), &productInfo{
+		productCategory: "Warning",
+		awipsID:         "TOROUN",
+		vtec:            codes,
+		ugc:             []nwwsio.UGCCode{{State: "OK", Type: "C", Number: 27}},
+		same:            []nwwsio.SAMECode{{State: 40, County: 27}},
+		polygons:        []nwwsio.Polygon{{{Lat: 35.0, Lon: -97.6}, {Lat: 35.4, Lon: -97.6}, {Lat: 35.4, Lon: -97.2}, {Lat: 35.0, Lon: -97.2}, {Lat: 35.0, Lon: -97.6}}},
+	})
+
+	subscriber := func(userID string, filters ...string) Subscription {
+		return Subscription{Subscriber: Subscriber{UserID: userID}, Filters: filters}
+	}
+	okc027 := nwwsio.UGCCode{State: "OK", Type: "C", Number: 27}
+	client.subscriptions.SubscribeToStation("KOUN", subscriber("station", "warning"))
+	client.subscriptions.SubscribeToArea(okc027, subscriber("area", "all"))
+	client.subscriptions.SubscribeToSAME(nwwsio.SAMECode{State: 40, County: 27}, subscriber("same", "to.w"))
+	client.subscriptions.SubscribeToPoint("norman", nwwsio.Point{Lat: 35.2, Lon: -97.4}, subscriber("point", "warning"))
+	client.subscriptions.SubscribeToArea(nwwsio.UGCCode{State: "MI", Type: "Z", Number: 68}, subscriber("elsewhere", "all"))
+	// Filters that would not have sent the product
+	client.subscriptions.SubscribeToStation("KOUN", subscriber("aviation", "aviation"))
+	client.subscriptions.SubscribeToArea(okc027, subscriber("cap", "cap"))
+	client.subscriptions.SubscribeToArea(okc027, subscriber("watch", "watch"))
+	// Subscribed several ways, still told once
+	client.subscriptions.SubscribeToStation("KOUN", subscriber("both", "aviation"))
+	client.subscriptions.SubscribeToArea(okc027, subscriber("both", "warning"))
+
+	notify := func(first int) map[string]int {
+		t.Helper()
+
+		offset := len(core.Sent())
+		client.notifyPossibleMissedProducts(&SequenceGap{
+			Site:      "test",
+			ProcessID: "100",
+			First:     first,
+			Last:      first + 1,
+			After:     received.Add(-time.Minute),
+			Before:    received,
+		})
+
+		// Messages are sent synchronously, so everything has arrived
+		got := make(map[string]int)
+		for _, msg := range core.Sent()[offset:] {
+			if !msg.Private || !strings.Contains(msg.Text, "Possible missed products") ||
+				!strings.Contains(msg.Text, "Tornado Warning #42 (check !noaa recent KOUN)") {
+				t.Errorf("unexpected message %+v", msg)
+			}
+			got[msg.Target]++
+		}
+		return got
+	}
+
+	want := map[string]int{"station": 1, "area": 1, "same": 1, "point": 1, "both": 1}
+	if got := notify(3); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got notices %v, want %v", got, want)
+	}
+
+	// Later gaps during the same event don't repeat the notice
+	if got := notify(10); len(got) != 0 {
+		t.Errorf("got notices %v for a second gap", got)
+	}
+}
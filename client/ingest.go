@@ -0,0 +1,160 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+	"gosrc.io/xmpp"
+	"gosrc.io/xmpp/stanza"
+)
+
+// How long a product is remembered for cross-site deduplication. The same
+// product normally arrives from both sites within seconds.
+const DedupWindow = 10 * time.Minute
+
+// DefaultNWWSServers are the NWWS-OI servers, in order of preference
+var DefaultNWWSServers = []string{
+	fmt.Sprintf("%s:%s", NWWSCollegePark, NWWSServerPort),
+	fmt.Sprintf("%s:%s", NWWSBoulder, NWWSServerPort),
+}
+
+// nwwsSiteNames are the short names used in logs and MUC nicknames for the known sites
+var nwwsSiteNames = map[string]string{
+	NWWSCollegePark: "cprk",
+	NWWSBoulder:     "bldr",
+}
+
+// siteName returns the short name for a host:port server address
+func siteName(server string) string {
+	host, _, _ := strings.Cut(server, ":")
+	if name, ok := nwwsSiteNames[host]; ok {
+		return name
+	}
+	return host
+}
+
+// ProductKey identifies a product independently of the site it arrived from
+// using its WMO heading, AWIPS ID, issue time and a hash of its text
+func ProductKey(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension) string {
+	// Sites may differ in trailing whitespace, so hash a normalized copy
+	lines := strings.Split(strings.TrimSpace(messageNWWSIOX.Text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+
+	return fmt.Sprintf("%s %s|%s|%s|%s",
+		messageNWWSIOX.Ttaaii,
+		messageNWWSIOX.Cccc,
+		messageNWWSIOX.AwipsID,
+		messageNWWSIOX.Issue,
+		hex.EncodeToString(sum[:16]))
+}
+
+type dedupEntry struct {
+	key  string
+	seen time.Time
+}
+
+// Deduplicator drops products that were already received from another site
+type Deduplicator struct {
+	mu           sync.Mutex
+	window       time.Duration
+	seen         map[string]string    // product key -> site it first arrived from
+	order        []dedupEntry         // oldest first, for expiring keys
+	siteLastSeen map[string]time.Time // site -> when it last delivered a product
+}
+
+func NewDeduplicator(window time.Duration) *Deduplicator {
+	return &Deduplicator{
+		window:       window,
+		seen:         make(map[string]string),
+		siteLastSeen: make(map[string]time.Time),
+	}
+}
+
+// Check records a product received from a site and reports whether it is a
+// duplicate of one already received
+func (d *Deduplicator) Check(site, key string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.siteLastSeen[site] = now
+
+	// Expire keys that have left the window
+	cutoff := now.Add(-d.window)
+	expired := 0
+	for expired < len(d.order) && d.order[expired].seen.Before(cutoff) {
+		delete(d.seen, d.order[expired].key)
+		expired++
+	}
+	d.order = d.order[expired:]
+
+	if _, exists := d.seen[key]; exists {
+		return true
+	}
+
+	d.seen[key] = site
+	d.order = append(d.order, dedupEntry{key: key, seen: now})
+	return false
+}
+
+// OtherSiteActive reports whether any site other than the given one delivered
+// a product after since, meaning it may have covered products missed by this site
+func (d *Deduplicator) OtherSiteActive(site string, since time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for other, lastSeen := range d.siteLastSeen {
+		if other != site && lastSeen.After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// newXMPPConfig builds the XMPP configuration for an NWWS-OI login. The
+// server address is filled in by the connection supervisor.
+func newXMPPConfig(nwwsioUsername, nwwsioPassword, resource string) xmpp.Config {
+	return xmpp.Config{
+		TransportConfiguration: xmpp.TransportConfiguration{
+			Domain: NWWSDomain,
+		},
+		Jid:            fmt.Sprintf("%s@%s/%s", nwwsioUsername, NWWSDomain, resource),
+		Credential:     xmpp.Password(nwwsioPassword),
+		Insecure:       false,
+		ConnectTimeout: int(ConnectionTimeout.Seconds()),
+	}
+}
+
+// newMUCJID returns the MUC occupant JID for a nickname
+func newMUCJID(nickname string) *stanza.Jid {
+	return &stanza.Jid{
+		Node:     "nwws",
+		Domain:   "conference.nwws-oi.weather.gov",
+		Resource: nickname,
+	}
+}
+
+// NewDualSiteNWWSIOClients returns one supervised connection per server so
+// products keep arriving while any one site is down. Each connection joins
+// the MUC with its own nickname and products are deduplicated before they
+// are processed.
+func NewDualSiteNWWSIOClients(nwwsioUsername, nwwsioPassword, instanceID string, servers []string, handler ProductHandler) []*ConnectionSupervisor {
+	var sites []*ConnectionSupervisor
+	for _, server := range servers {
+		name := siteName(server)
+		config := newXMPPConfig(nwwsioUsername, nwwsioPassword, fmt.Sprintf("%s-%s-%s", NWWSResource, instanceID, name))
+		mucJID := newMUCJID(fmt.Sprintf("%s-%s-%s", nwwsioUsername, instanceID, name))
+
+		log.Info().Str("site", name).Str("server", server).Msg("Configured NWWS-IO site for dual-site ingest")
+		sites = append(sites, newConnectionSupervisor(name, []string{server}, config, mucJID, handler))
+	}
+	return sites
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Metrics are published with expvar and served as JSON from /debug/vars when
+// a metrics address is configured
+var (
+	metricProductsReceived   = expvar.NewInt("nwwsio_products_received")
+	metricSequenceGaps       = expvar.NewInt("nwwsio_sequence_gaps")
+	metricMissedProducts     = expvar.NewInt("nwwsio_missed_products")
+	metricLateProducts       = expvar.NewInt("nwwsio_late_products")
+	metricProcessRestarts    = expvar.NewInt("nwwsio_process_restarts")
+	metricDuplicateProducts  = expvar.NewInt("nwwsio_duplicate_products")
+	metricConnectionFailures = expvar.NewInt("nwwsio_connection_failures")
+	metricDisconnects        = expvar.NewInt("nwwsio_disconnects")
+)
+
+const metricsShutdownTimeout = 5 * time.Second
+
+// serveMetrics serves the expvar metrics on addr until the context is cancelled
+func serveMetrics(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Str("addr", addr).Msg("Serving metrics")
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// ProductSource produces NWWS-OI products, handing each one to the
+// ProductHandler it was created with
+type ProductSource interface {
+	// Run produces products until the source is stopped or exhausted
+	Run() error
+	Stop()
+}
+
+// ProductHandler accepts products from a source
+type ProductHandler interface {
+	HandleProduct(site string, messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, received time.Time)
+}
+
+// SubscriptionStore finds the subscriptions a product should be delivered to
+// and keeps the recent history shown by commands
+type SubscriptionStore interface {
+	GetStationSubscriptions(stationCode string) []Subscription
+	GetAreaSubscriptions(codes []nwwsio.UGCCode) []Subscription
+	GetSAMESubscriptions(codes []nwwsio.SAMECode) []Subscription
+	GetPointSubscriptions(polygons []nwwsio.Polygon) []PointSubscription
+	AddRecentMessage(msg RecentMessage) (superseded RecentMessage, found bool)
+}
+
+// MessageSink delivers formatted alerts to channels and users
+type MessageSink interface {
+	SendMessage(channelID, text string)
+	SendPrivateMessage(userID, text string)
+}
+
+var (
+	_ ProductSource     = (*ConnectionSupervisor)(nil)
+	_ ProductSource     = (*CaptureSource)(nil)
+	_ ProductHandler    = (*Pipeline)(nil)
+	_ SubscriptionStore = (*SubscriptionManager)(nil)
+	_ MessageSink       = (*SeabirdClient)(nil)
+)
+
+// Product carries one NWWS-OI product through the pipeline stages
+type Product struct {
+	Site     string
+	Received time.Time
+	Message  *nwwsio.NWWSOIMessageXExtension
+
+	info        *productInfo   // set by decode and enrich
+	displayName string         // set by enrich
+	matches     []productMatch // set by match, one per subscriber
+	alert       string         // set by format, for subscribers receiving the whole product
+}
+
+// productMatch is a subscriber a product is delivered to
+type productMatch struct {
+	Subscription
+	segments []int  // indexes of the segments for the subscriber's areas, nil for the whole product
+	alert    string // set by format when only some segments are delivered
+}
+
+// addSegment widens the match to another segment, or the whole product when
+// segment is negative
+func (m *productMatch) addSegment(segment int) {
+	if m.segments == nil {
+		return
+	}
+	if segment < 0 {
+		m.segments = nil
+		return
+	}
+	for _, existing := range m.segments {
+		if existing == segment {
+			return
+		}
+	}
+	m.segments = append(m.segments, segment)
+}
+
+// IntakeFunc sees every product before it is decoded, returning false to drop it
+type IntakeFunc func(product *Product) bool
+
+// Pipeline takes received products through decode, enrich, record, match,
+// format and deliver stages
+type Pipeline struct {
+	subscriptions SubscriptionStore
+	events        *EventTracker
+	sink          MessageSink
+	archive       *ProductArchive // nil when archiving is disabled
+	intake        []IntakeFunc
+}
+
+func NewPipeline(subscriptions SubscriptionStore, events *EventTracker, sink MessageSink) *Pipeline {
+	return &Pipeline{
+		subscriptions: subscriptions,
+		events:        events,
+		sink:          sink,
+	}
+}
+
+// SetArchive archives every decoded product in the record stage
+func (p *Pipeline) SetArchive(archive *ProductArchive) {
+	p.archive = archive
+}
+
+// AddIntake adds a function run on every product before decoding, in the
+// order added
+func (p *Pipeline) AddIntake(fn IntakeFunc) {
+	p.intake = append(p.intake, fn)
+}
+
+// HandleProduct runs a received product through every stage
+func (p *Pipeline) HandleProduct(site string, messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, received time.Time) {
+	product := &Product{Site: site, Received: received, Message: messageNWWSIOX}
+
+	for _, fn := range p.intake {
+		if !fn(product) {
+			return
+		}
+	}
+
+	if err := p.decode(product); err != nil {
+		log.Warn().Err(err).Str("ttaaii", messageNWWSIOX.Ttaaii).Msg("Failed to parse product info")
+		return
+	}
+	p.enrich(product)
+	p.record(product)
+	p.match(product)
+	if len(product.matches) == 0 {
+		return
+	}
+	p.format(product)
+	p.deliver(product)
+}
+
+// decode parses the WMO heading, AWIPS ID, CAP payload and the VTEC, UGC
+// and polygon blocks from the product
+func (p *Pipeline) decode(product *Product) error {
+	info, err := parseProductInfo(product.Message)
+	if err != nil {
+		return err
+	}
+	product.info = info
+	return nil
+}
+
+// enrich applies VTEC actions to the active event registry, recording the
+// areas each event covers, and names the product
+func (p *Pipeline) enrich(product *Product) {
+	issued := parseIssueTime(product.Message.Issue)
+	if segments := product.info.segments; segments != nil && segments.IsSegmented() {
+		codes := make([][]nwwsio.VTEC, len(segments.Segments))
+		for i := range segments.Segments {
+			codes[i] = segments.Segments[i].VTEC
+		}
+		product.info.eventUpdates = p.events.ApplySegments(codes, issued)
+	} else {
+		product.info.eventUpdates = p.events.ApplyProduct(product.info.vtec, issued)
+	}
+	for _, update := range product.info.eventUpdates {
+		p.events.setProduct(update.Event.Key(), eventProductInfo(product.info, update.VTEC))
+	}
+	logProductReceipt(product.Message, product.info)
+	product.displayName = buildDisplayName(product.info)
+}
+
+// record stores the product in recent history and the archive. Corrections
+// and amendments replace the version they supersede in recent history.
+func (p *Pipeline) record(product *Product) {
+	msg := RecentMessage{
+		ID:        product.Message.ID,
+		Station:   product.Message.Cccc,
+		DataType:  product.displayName,
+		AwipsID:   product.Message.AwipsID,
+		Issue:     product.Message.Issue,
+		Text:      product.Message.Text,
+		Timestamp: product.Received,
+	}
+	if heading := product.info.heading; heading != nil {
+		msg.Heading = heading.Key()
+		msg.BBB = heading.BBB
+	}
+	if superseded, found := p.subscriptions.AddRecentMessage(msg); found {
+		product.info.supersedes = superseded.ID
+		log.Info().
+			Str("id", product.Message.ID).
+			Str("superseded_id", superseded.ID).
+			Str("bbb", msg.BBB).
+			Msg("Product supersedes an earlier version")
+	}
+
+	if p.archive != nil {
+		if err := p.archive.Add(buildArchivedProduct(product.Message, product.info, product.displayName, product.Received)); err != nil {
+			log.Error().Err(err).Str("id", product.Message.ID).Msg("Failed to archive product")
+		}
+	}
+}
+
+// match finds the subscribers whose filters accept the product. Station
+// subscriptions match the issuing office, area and SAME subscriptions match
+// any county or zone the product covers and point subscriptions match saved
+// locations inside the warning polygon. When a product has segments for
+// different areas, area and point subscribers only match the segments
+// covering them, and their filters are checked against those segments alone.
+func (p *Pipeline) match(product *Product) {
+	info := product.info
+
+	segmented := info.segments != nil && info.segments.IsSegmented()
+	var segmentInfos []*productInfo
+	if segmented {
+		segmentInfos = make([]*productInfo, len(info.segments.Segments))
+		for i := range segmentInfos {
+			segmentInfos[i] = partialProductInfo(info, []int{i})
+		}
+	}
+
+	// Subscribers may match through several subscriptions but should only get one copy
+	matched := make(map[Subscriber]int)
+	add := func(subscriptions []Subscription, segment int) {
+		filtered := info
+		if segment >= 0 {
+			filtered = segmentInfos[segment]
+		}
+		for _, sub := range subscriptions {
+			if !shouldSendToSubscriber(sub, filtered) {
+				continue
+			}
+			if i, found := matched[sub.Subscriber]; found {
+				product.matches[i].addSegment(segment)
+				continue
+			}
+
+			match := productMatch{Subscription: sub}
+			if segment >= 0 {
+				match.segments = []int{segment}
+			}
+			matched[sub.Subscriber] = len(product.matches)
+			product.matches = append(product.matches, match)
+		}
+	}
+
+	add(p.subscriptions.GetStationSubscriptions(product.Message.Cccc), -1)
+	if segmented {
+		for i, segment := range info.segments.Segments {
+			add(p.subscriptions.GetAreaSubscriptions(segment.Codes()), i)
+		}
+	} else {
+		add(p.subscriptions.GetAreaSubscriptions(info.ugc), -1)
+	}
+	add(p.subscriptions.GetSAMESubscriptions(info.same), -1)
+	if segmented {
+		for i, segment := range info.segments.Segments {
+			add(pointSubscriptions(p.subscriptions.GetPointSubscriptions(segment.Polygons())), i)
+		}
+	} else {
+		add(pointSubscriptions(p.subscriptions.GetPointSubscriptions(info.polygons)), -1)
+	}
+}
+
+// pointSubscriptions returns the subscriptions of the saved locations
+func pointSubscriptions(points []PointSubscription) []Subscription {
+	subscriptions := make([]Subscription, len(points))
+	for i, point := range points {
+		subscriptions[i] = point.Subscription
+	}
+	return subscriptions
+}
+
+// format builds the alert text sent to subscribers. Subscribers matching only
+// some segments get an alert built from just those segments.
+func (p *Pipeline) format(product *Product) {
+	formatted := make(map[string]string)
+	for i := range product.matches {
+		match := &product.matches[i]
+		if match.segments == nil {
+			if product.alert == "" {
+				product.alert = formatAlertMessage(product.Message, product.info)
+			}
+			continue
+		}
+
+		sort.Ints(match.segments)
+		key := fmt.Sprint(match.segments)
+		alert, found := formatted[key]
+		if !found {
+			partial := *product.Message
+			partial.Text = product.info.segments.Text(match.segments)
+			alert = formatAlertMessage(&partial, partialProductInfo(product.info, match.segments))
+			formatted[key] = alert
+		}
+		match.alert = alert
+	}
+}
+
+// partialProductInfo returns the product info for some of a segmented
+// product's segments. VTEC, event updates, areas, threat tags and
+// escalations come from those segments alone, so subscribers aren't told
+// about cancellations or emergencies elsewhere.
+func partialProductInfo(info *productInfo, segments []int) *productInfo {
+	partial := *info
+	partial.vtec, partial.ugc, partial.polygons = nil, nil, nil
+	for _, i := range segments {
+		segment := &info.segments.Segments[i]
+		partial.vtec = append(partial.vtec, segment.VTEC...)
+		partial.ugc = append(partial.ugc, segment.Codes()...)
+		partial.polygons = append(partial.polygons, segment.Polygons()...)
+	}
+
+	partial.eventUpdates = nil
+	for _, update := range info.eventUpdates {
+		for _, vtec := range partial.vtec {
+			if vtec.Action == update.VTEC.Action && sameEvent(vtec, update.VTEC) {
+				partial.eventUpdates = append(partial.eventUpdates, update)
+				break
+			}
+		}
+	}
+
+	text := info.segments.Text(segments)
+	partial.tags = nwwsio.FindThreatTags(text)
+	partial.escalations = nil
+	if hasEscalations(&partial) {
+		partial.escalations = nwwsio.FindEscalations(text, partial.tags)
+	}
+	return &partial
+}
+
+// eventProductInfo returns the part of a product about an event. In
+// segmented products this is the segments continuing the event.
+func eventProductInfo(info *productInfo, vtec nwwsio.VTEC) *productInfo {
+	if info.segments == nil || !info.segments.IsSegmented() {
+		return info
+	}
+
+	var segments []int
+	for i := range info.segments.Segments {
+		for _, code := range info.segments.Segments[i].VTEC {
+			if sameEvent(code, vtec) && !isTerminalAction(code.Action) {
+				segments = append(segments, i)
+				break
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return info
+	}
+	return partialProductInfo(info, segments)
+}
+
+// deliver sends the alert to every matched subscriber
+func (p *Pipeline) deliver(product *Product) {
+	for _, sub := range product.matches {
+		alert := product.alert
+		if sub.segments != nil {
+			alert = sub.alert
+		}
+
+		if sub.IsChannel() {
+			p.sink.SendMessage(sub.ChannelID, alert)
+		} else {
+			p.sink.SendPrivateMessage(sub.UserID, alert)
+		}
+		log.Info().
+			Str("user_id", sub.UserID).
+			Str("channel_id", sub.ChannelID).
+			Str("station", product.Message.Cccc).
+			Strs("filters", sub.Filters).
+			Str("product_category", product.info.productCategory).
+			Bool("is_cap", product.info.capAlert != nil).
+			Ints("segments", sub.segments).
+			Msg("Sent weather alert to subscriber")
+	}
+}
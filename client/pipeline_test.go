@@ -0,0 +1,318 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// recordingSink is a MessageSink which keeps every message
+type recordingSink struct {
+	mu   sync.Mutex
+	sent []sentMessage
+}
+
+func (s *recordingSink) SendMessage(channelID, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, sentMessage{Target: channelID, Text: text})
+}
+
+func (s *recordingSink) SendPrivateMessage(userID, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, sentMessage{Private: true, Target: userID, Text: text})
+}
+
+func (s *recordingSink) Sent() []sentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]sentMessage(nil), s.sent...)
+}
+
+func (p fakeProduct) extension() *nwwsio.NWWSOIMessageXExtension {
+	return &nwwsio.NWWSOIMessageXExtension{
+		ID:      p.ID,
+		Cccc:    p.Cccc,
+		Ttaaii:  p.Ttaaii,
+		Issue:   p.Issue,
+		AwipsID: p.AwipsID,
+		Text:    p.Text,
+	}
+}
+
+func newTestPipeline() (*Pipeline, *SubscriptionManager, *recordingSink) {
+	subscriptions := NewSubscriptionManager()
+	sink := &recordingSink{}
+	return NewPipeline(subscriptions, NewEventTracker(), sink), subscriptions, sink
+}
+
+func TestPipelineDeliversToMatchingSubscribers(t *testing.T) {
+	pipeline, subscriptions, sink := newTestPipeline()
+	subscriptions.SubscribeToStation("KOUN", Subscription{Subscriber: Subscriber{UserID: "alice"}, Filters: []string{"warning"}})
+	subscriptions.SubscribeToStation("KOUN", Subscription{Subscriber: Subscriber{UserID: "bob"}, Filters: []string{"cap"}})
+	subscriptions.SubscribeToArea(nwwsio.UGCCode{State: "OK", Type: "C", Number: 27}, Subscription{Subscriber: Subscriber{ChannelID: testChannel}, Filters: []string{"all"}})
+
+	pipeline.HandleProduct("test", tornadoWarning("1001.1").extension(), time.Now())
+
+	sent := sink.Sent()
+	want := []sentMessage{
+		privateMsg("alice", "[KOUN] Tornado Warning"),
+		channelMsg("ID: 1001.1"),
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("sent %d messages, want %d: %v", len(sent), len(want), sent)
+	}
+	for i := range want {
+		if sent[i].Private != want[i].Private || sent[i].Target != want[i].Target || !strings.Contains(sent[i].Text, want[i].Text) {
+			t.Errorf("message %d:\ngot  %+v\nwant %+v", i, sent[i], want[i])
+		}
+	}
+
+	if _, found := subscriptions.FindRecentMessage("1001.1"); !found {
+		t.Error("product not recorded in recent history")
+	}
+}
+
+func TestPipelineIntakeCanDropProducts(t *testing.T) {
+	pipeline, subscriptions, sink := newTestPipeline()
+	subscriptions.SubscribeToStation("KOUN", Subscription{Subscriber: Subscriber{UserID: "alice"}, Filters: []string{"all"}})
+
+	var seen []string
+	pipeline.AddIntake(func(product *Product) bool {
+		seen = append(seen, product.Message.ID)
+		return product.Site != "dropped"
+	})
+
+	pipeline.HandleProduct("dropped", tornadoWarning("1001.1").extension(), time.Now())
+	pipeline.HandleProduct("kept", tornadoWarning("1001.2").extension(), time.Now())
+
+	if strings.Join(seen, ",") != "1001.1,1001.2" {
+		t.Errorf("intake saw %v", seen)
+	}
+	if _, found := subscriptions.FindRecentMessage("1001.1"); found {
+		t.Error("dropped product was recorded")
+	}
+	if sent := sink.Sent(); len(sent) != 1 || !strings.Contains(sent[0].Text, "ID: 1001.2") {
+		t.Errorf("unexpected messages %v", sent)
+	}
+}
+
+func TestCaptureSourceReplaysIntoPipeline(t *testing.T) {
+	pipeline, subscriptions, sink := newTestPipeline()
+	subscriptions.SubscribeToStation("KOUN", Subscription{Subscriber: Subscriber{UserID: "alice"}, Filters: []string{"all"}})
+
+	var capture bytes.Buffer
+	for _, line := range []string{
+		`{"Received":"2026-05-06T22:14:00Z","Site":"cprk","ID":"1001.1","Cccc":"KOUN","Ttaaii":"NOUS44","Issue":"2026-05-06T22:14:00Z","AwipsID":"PNSOUN","Text":"first"}`,
+		``,
+		`{"Received":"2026-05-06T22:14:01Z","ID":"1001.2","Cccc":"KOUN","Ttaaii":"NOUS44","Issue":"2026-05-06T22:14:01Z","AwipsID":"PNSOUN","Text":"second"}`,
+	} {
+		capture.WriteString(line + "\n")
+	}
+
+	var sites []string
+	var received []time.Time
+	pipeline.AddIntake(func(product *Product) bool {
+		sites = append(sites, product.Site)
+		received = append(received, product.Received)
+		return true
+	})
+
+	source := NewCaptureSource(&capture, 0, pipeline)
+	if err := source.Run(); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if source.Count() != 2 {
+		t.Errorf("replayed %d products, want 2", source.Count())
+	}
+	if strings.Join(sites, ",") != "cprk,replay" {
+		t.Errorf("products came from sites %v", sites)
+	}
+	if want := time.Date(2026, 5, 6, 22, 14, 1, 0, time.UTC); len(received) != 2 || !received[1].Equal(want) {
+		t.Errorf("products received at %v, want the captured times", received)
+	}
+	if sent := sink.Sent(); len(sent) != 2 {
+		t.Errorf("sent %d messages, want 2", len(sent))
+	}
+}
+
+func TestReplayStopsWithContext(t *testing.T) {
+	client, err := NewSeabirdClient(Config{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.Shutdown()
+	})
+
+	// The second product is an hour after the first, so at normal speed the
+	// replay waits until the context is cancelled
+	capture := strings.NewReader(
+		`{"Received":"2026-05-06T22:00:00Z","ID":"1.1","Cccc":"KOUN","Ttaaii":"NOUS44","AwipsID":"PNSOUN","Text":"first"}` + "\n" +
+			`{"Received":"2026-05-06T23:00:00Z","ID":"1.2","Cccc":"KOUN","Ttaaii":"NOUS44","AwipsID":"PNSOUN","Text":"second"}` + "\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	count, err := client.Replay(ctx, capture, 1)
+	if err != context.DeadlineExceeded {
+		t.Errorf("replay returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if count != 1 {
+		t.Errorf("replayed %d products, want 1", count)
+	}
+}
+
+func TestPipelineCorrectionSupersedesOriginal(t *testing.T) {
+	pipeline, subscriptions, sink := newTestPipeline()
+	subscriptions.SubscribeToStation("KOUN", Subscription{Subscriber: Subscriber{UserID: "alice"}, Filters: []string{"all"}})
+
+	original := tornadoWarning("1001.1")
+	correction := tornadoWarning("1001.2")
+	correction.Text = strings.Replace(correction.Text, "WFUS54 KOUN 062214", "WFUS54 KOUN 062214 CCA", 1)
+	correction.Text = strings.Replace(correction.Text, "/O.NEW.", "/O.COR.", 1)
+
+	pipeline.HandleProduct("test", original.extension(), time.Now())
+	pipeline.HandleProduct("test", correction.extension(), time.Now())
+
+	recent := subscriptions.GetRecentMessages("KOUN")
+	if len(recent) != 1 || recent[0].ID != "1001.2" || recent[0].BBB != "CCA" {
+		t.Fatalf("recent history is %+v, want only the correction", recent)
+	}
+
+	sent := sink.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(sent))
+	}
+	if strings.Contains(sent[0].Text, "CORRECTION") {
+		t.Errorf("original marked as a correction:\n%s", sent[0].Text)
+	}
+	if !strings.HasPrefix(sent[1].Text, "CORRECTION (CCA) replaces ID 1001.1\n") {
+		t.Errorf("correction not marked:\n%s", sent[1].Text)
+	}
+}
+
+func TestPipelineDeliversSegmentsForSubscribedAreas(t *testing.T) {
+	pipeline, subscriptions, sink := newTestPipeline()
+	subscriptions.SubscribeToArea(nwwsio.UGCCode{State: "MI", Type: "Z", Number: 48}, Subscription{Subscriber: Subscriber{UserID: "alice"}, Filters: []string{"all"}})
+	subscriptions.SubscribeToArea(nwwsio.UGCCode{State: "MI", Type: "Z", Number: 54}, Subscription{Subscriber: Subscriber{UserID: "bob"}, Filters: []string{"all"}})
+	subscriptions.SubscribeToStation("KDTX", Subscription{Subscriber: Subscriber{UserID: "carol"}, Filters: []string{"all"}})
+
+	product := fakeProduct{
+		ID:      "4001.1",
+		Cccc:    "KDTX",
+		Ttaaii:  "WWUS43",
+		Issue:   "2026-01-15T09:00:00Z",
+		AwipsID: "WSWDTX",
+		Text: "\n\n123 \nWWUS43 KDTX 150900\nWSWDTX\n\nURGENT - WINTER WEATHER MESSAGE\n" +
+			"National Weather Service Detroit/Pontiac MI\n400 AM EST Mon Jan 15 2026\n\n" +
+			"MIZ047>049-151700-\n/O.EXT.KDTX.WW.Y.0003.000000T0000Z-260115T1700Z/\nMidland-Bay-Huron-\n\n" +
+			"...WINTER WEATHER ADVISORY REMAINS IN EFFECT UNTIL NOON EST TODAY...\n\n$$\n\n" +
+			"MIZ053>055-151700-\n/O.CAN.KDTX.WW.Y.0003.000000T0000Z-260115T1700Z/\nGenesee-Lapeer-St. Clair-\n\n" +
+			"...WINTER WEATHER ADVISORY IS CANCELLED...\n\n$$\n\nSMITH\n",
+	}
+	pipeline.HandleProduct("test", product.extension(), time.Now())
+
+	alerts := make(map[string]string)
+	for _, msg := range sink.Sent() {
+		alerts[msg.Target] = msg.Text
+	}
+	if len(alerts) != 3 {
+		t.Fatalf("sent to %d subscribers, want 3: %v", len(alerts), alerts)
+	}
+
+	for _, check := range []struct {
+		user    string
+		include []string
+		exclude []string
+	}{
+		{"alice", []string{"REMAINS IN EFFECT", "SMITH"}, []string{"IS CANCELLED"}},
+		{"bob", []string{"IS CANCELLED", "SMITH"}, []string{"REMAINS IN EFFECT"}},
+		{"carol", []string{"REMAINS IN EFFECT", "IS CANCELLED"}, nil},
+	} {
+		for _, text := range check.include {
+			if !strings.Contains(alerts[check.user], text) {
+				t.Errorf("alert for %s is missing %q:\n%s", check.user, text, alerts[check.user])
+			}
+		}
+		for _, text := range check.exclude {
+			if strings.Contains(alerts[check.user], text) {
+				t.Errorf("alert for %s includes %q:\n%s", check.user, text, alerts[check.user])
+			}
+		}
+	}
+}
+
+func TestPipelineSegmentInfoForSubscribedAreas(t *testing.T) {
+	pipeline, subscriptions, sink := newTestPipeline()
+	pipeline.HandleProduct("test", tornadoWarning("4002.1").extension(), time.Now())
+
+	cleveland := nwwsio.UGCCode{State: "OK", Type: "C", Number: 27}
+	mcclain := nwwsio.UGCCode{State: "OK", Type: "C", Number: 87}
+	subscriptions.SubscribeToArea(cleveland, Subscription{Subscriber: Subscriber{UserID: "alice"}, Filters: []string{"tornado:observed"}})
+	subscriptions.SubscribeToArea(cleveland, Subscription{Subscriber: Subscriber{UserID: "bob"}, Filters: []string{"all"}})
+	subscriptions.SubscribeToArea(mcclain, Subscription{Subscriber: Subscriber{UserID: "carol"}, Filters: []string{"all"}})
+	subscriptions.SubscribeToStation("KOUN", Subscription{Subscriber: Subscriber{UserID: "dave"}, Filters: []string{"all"}})
+
+	product := fakeProduct{
+		ID:      "4002.2",
+		Cccc:    "KOUN",
+		Ttaaii:  "WWUS54",
+		Issue:   "2026-05-06T22:30:00Z",
+		AwipsID: "SVSOUN",
+		Text: "\n\n301 \nWWUS54 KOUN 062230\nSVSOUN\n\nSevere Weather Statement\n" +
+			"National Weather Service Norman OK\n530 PM CDT Wed May 6 2026\n\n" +
+			"OKC027-062245-\n/O.CAN.KOUN.TO.W.0042.000000T0000Z-260506T2245Z/\nCleveland-\n\n" +
+			"...THE TORNADO WARNING FOR CLEVELAND COUNTY IS CANCELLED...\n\n$$\n\n" +
+			"OKC087-062245-\n/O.CON.KOUN.TO.W.0042.000000T0000Z-260506T2245Z/\nMcClain-\n\n" +
+			"...A TORNADO EMERGENCY REMAINS IN EFFECT FOR MCCLAIN COUNTY...\n\n" +
+			"LAT...LON 3500 9760 3520 9760 3520 9740 3500 9740\n\n" +
+			"TORNADO...OBSERVED\nTORNADO DAMAGE THREAT...CATASTROPHIC\n\n$$\n\nSMITH\n",
+	}
+	pipeline.HandleProduct("test", product.extension(), time.Now())
+
+	alerts := make(map[string]string)
+	for _, msg := range sink.Sent() {
+		if strings.Contains(msg.Text, product.ID) {
+			alerts[msg.Target] = msg.Text
+		}
+	}
+	if _, found := alerts["alice"]; found {
+		t.Errorf("alice's tornado:observed filter matched the cancelled segment:\n%s", alerts["alice"])
+	}
+
+	for _, check := range []struct {
+		user    string
+		include []string
+		exclude []string
+	}{
+		{"bob", []string{"CANCELLED: Tornado Warning #42"}, []string{"CONTINUES", "!!!", "Tornado observed"}},
+		{"carol", []string{"!!! TORNADO EMERGENCY !!!", "CONTINUES: Tornado Warning #42", "Tornado observed"}, []string{"CANCELLED"}},
+		{"dave", []string{"!!! TORNADO EMERGENCY !!!", "CANCELLED", "CONTINUES"}, nil},
+	} {
+		alert, found := alerts[check.user]
+		if !found {
+			t.Errorf("nothing sent to %s", check.user)
+			continue
+		}
+		for _, text := range check.include {
+			if !strings.Contains(alert, text) {
+				t.Errorf("alert for %s is missing %q:\n%s", check.user, text, alert)
+			}
+		}
+		for _, text := range check.exclude {
+			if strings.Contains(alert, text) {
+				t.Errorf("alert for %s includes %q:\n%s", check.user, text, alert)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-go/pb"
+	"google.golang.org/grpc"
+)
+
+// sentMessage is a message the plugin sent through seabird-core
+type sentMessage struct {
+	Private bool   // sent with SendPrivateMessage
+	Target  string // channel ID, or user ID for private messages
+	Text    string
+}
+
+// fakeSeabirdCore is an in-process stand-in for seabird-core. It streams
+// injected command events to the plugin and records every message the plugin
+// sends.
+type fakeSeabirdCore struct {
+	pb.UnimplementedSeabirdServer
+
+	t        *testing.T
+	listener net.Listener
+	server   *grpc.Server
+	events   chan *pb.Event
+	streams  chan map[string]*pb.CommandMetadata // commands registered by each StreamEvents call
+
+	mu      sync.Mutex
+	sent    []sentMessage
+	newSent chan struct{} // closed and replaced whenever a message is recorded
+}
+
+// newFakeSeabirdCore starts a fake seabird-core on a loopback port. It is
+// stopped when the test finishes.
+func newFakeSeabirdCore(t *testing.T) *fakeSeabirdCore {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	core := &fakeSeabirdCore{
+		t:        t,
+		listener: listener,
+		server:   grpc.NewServer(),
+		events:   make(chan *pb.Event),
+		streams:  make(chan map[string]*pb.CommandMetadata, 4),
+		newSent:  make(chan struct{}),
+	}
+	pb.RegisterSeabirdServer(core.server, core)
+	go func() {
+		_ = core.server.Serve(listener)
+	}()
+	t.Cleanup(core.server.Stop)
+	return core
+}
+
+// URL returns the address to configure as the seabird-core URL
+func (core *fakeSeabirdCore) URL() string {
+	return "http://" + core.listener.Addr().String()
+}
+
+func (core *fakeSeabirdCore) StreamEvents(req *pb.StreamEventsRequest, stream pb.Seabird_StreamEventsServer) error {
+	core.streams <- req.Commands
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-core.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (core *fakeSeabirdCore) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
+	core.record(sentMessage{Target: req.ChannelId, Text: req.Text})
+	return &pb.SendMessageResponse{}, nil
+}
+
+func (core *fakeSeabirdCore) SendPrivateMessage(ctx context.Context, req *pb.SendPrivateMessageRequest) (*pb.SendPrivateMessageResponse, error) {
+	core.record(sentMessage{Private: true, Target: req.UserId, Text: req.Text})
+	return &pb.SendPrivateMessageResponse{}, nil
+}
+
+func (core *fakeSeabirdCore) record(msg sentMessage) {
+	core.mu.Lock()
+	defer core.mu.Unlock()
+
+	core.sent = append(core.sent, msg)
+	close(core.newSent)
+	core.newSent = make(chan struct{})
+}
+
+// Sent returns every message recorded so far
+func (core *fakeSeabirdCore) Sent() []sentMessage {
+	core.mu.Lock()
+	defer core.mu.Unlock()
+
+	result := make([]sentMessage, len(core.sent))
+	copy(result, core.sent)
+	return result
+}
+
+// WaitForStream waits for the plugin to open its event stream, returning the
+// commands it registered
+func (core *fakeSeabirdCore) WaitForStream() map[string]*pb.CommandMetadata {
+	core.t.Helper()
+
+	select {
+	case commands := <-core.streams:
+		return commands
+	case <-time.After(fakeWaitTimeout):
+		core.t.Fatal("timed out waiting for the event stream")
+		return nil
+	}
+}
+
+// SendCommand delivers a command event as if the user ran it in the channel
+func (core *fakeSeabirdCore) SendCommand(userID, channelID, command, arg string) {
+	core.t.Helper()
+
+	event := &pb.Event{Inner: &pb.Event_Command{Command: &pb.CommandEvent{
+		Source: &pb.ChannelSource{
+			ChannelId: channelID,
+			User:      &pb.User{Id: userID, DisplayName: userID},
+		},
+		Command: command,
+		Arg:     arg,
+	}}}
+
+	select {
+	case core.events <- event:
+	case <-time.After(fakeWaitTimeout):
+		core.t.Fatal("timed out delivering a command event")
+	}
+}
+
+// WaitForSent waits until more than offset messages have been recorded and
+// returns the first count of them after offset
+func (core *fakeSeabirdCore) WaitForSent(offset, count int) []sentMessage {
+	core.t.Helper()
+
+	deadline := time.After(fakeWaitTimeout)
+	for {
+		core.mu.Lock()
+		if len(core.sent) >= offset+count {
+			result := make([]sentMessage, count)
+			copy(result, core.sent[offset:])
+			core.mu.Unlock()
+			return result
+		}
+		newSent := core.newSent
+		got := len(core.sent) - offset
+		core.mu.Unlock()
+
+		select {
+		case <-newSent:
+		case <-deadline:
+			core.t.Fatalf("timed out waiting for %d messages, got %d: %v", count, got, core.Sent()[offset:])
+			return nil
+		}
+	}
+}
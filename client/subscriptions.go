@@ -11,27 +11,102 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
-	nwwsio "github.com/seabird-chat/seabird-nwwsio-plugin/internal"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
 )
 
 type RecentMessage struct {
+	ID        string // NWWS id attribute
 	Station   string
 	DataType  string
 	AwipsID   string
 	Issue     string
 	Text      string
 	Timestamp time.Time
+	Heading   string // WMO heading without the BBB indicator, shared by every version
+	BBB       string // WMO BBB indicator, empty for the original issuance
 }
 
-// Subscription represents a user's subscription to a station with filtering
+// supersedes reports whether the message is a correction or amendment of other
+func (msg RecentMessage) supersedes(other RecentMessage) bool {
+	if msg.Heading == "" || msg.Heading != other.Heading || msg.AwipsID != other.AwipsID {
+		return false
+	}
+	return strings.HasPrefix(msg.BBB, "CC") || strings.HasPrefix(msg.BBB, "AA")
+}
+
+// appendRecentMessage adds a message to a station's history, oldest first.
+// Corrections and amendments replace the version they supersede in place.
+func appendRecentMessage(messages []RecentMessage, msg RecentMessage) (result []RecentMessage, superseded RecentMessage, found bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if msg.supersedes(messages[i]) {
+			superseded = messages[i]
+			messages[i] = msg
+			return messages, superseded, true
+		}
+	}
+
+	messages = append(messages, msg)
+	if len(messages) > MaxRecentMessages {
+		messages = messages[1:]
+	}
+	return messages, RecentMessage{}, false
+}
+
+// Subscriber identifies where alerts are delivered, either a user by private
+// message or a channel. Exactly one of the fields is set.
+type Subscriber struct {
+	UserID    string `json:",omitempty"`
+	ChannelID string `json:",omitempty"`
+}
+
+// IsChannel reports whether alerts are posted to a channel rather than sent by DM
+func (s Subscriber) IsChannel() bool {
+	return s.ChannelID != ""
+}
+
+// String returns the user or channel ID
+func (s Subscriber) String() string {
+	if s.IsChannel() {
+		return s.ChannelID
+	}
+	return s.UserID
+}
+
+// Subscription represents a user's or channel's subscription with filtering
 type Subscription struct {
-	UserID  string
-	Filters []string // Filters: "cap", "all", or category names (Aviation, Hydrology, Marine, etc.)
+	Subscriber
+	AddedBy string   `json:",omitempty"` // User who added a channel subscription
+	Filters []string // Filters: "cap", "all", category names (Aviation, Hydrology, Marine, etc.) or constraints (e.g., "severity>=severe")
+}
+
+// PointSubscription is a subscription to warnings whose polygon contains a saved location
+type PointSubscription struct {
+	Subscription
+	Label    string
+	Location nwwsio.Point
+}
+
+// UserSubscription is one of a subscriber's station, area or SAME
+// subscriptions
+type UserSubscription struct {
+	Key     string // Station, UGC or SAME code
+	Filters []string
+}
+
+// subscriptionData is the on-disk format of the subscription file
+type subscriptionData struct {
+	Stations map[string][]Subscription // station code -> list of subscriptions
+	Areas    map[string][]Subscription // UGC county/zone code -> list of subscriptions
+	Points   []PointSubscription
+	Same     map[string][]Subscription // SAME location code -> list of subscriptions
 }
 
 type SubscriptionManager struct {
 	mu                 sync.RWMutex
 	stationSubscribers map[string][]Subscription  // station code -> list of subscriptions
+	areaSubscribers    map[string][]Subscription  // UGC county/zone code -> list of subscriptions
+	pointSubscribers   []PointSubscription        // saved locations matched against warning polygons
+	sameSubscribers    map[string][]Subscription  // SAME location code -> list of subscriptions
 	recentMessages     map[string][]RecentMessage // station code -> recent messages (last 5)
 	filePath           string                     // path to persistence file
 	autoSaveChan       chan struct{}              // signal channel for auto-save
@@ -41,6 +116,8 @@ type SubscriptionManager struct {
 func NewSubscriptionManager() *SubscriptionManager {
 	return &SubscriptionManager{
 		stationSubscribers: make(map[string][]Subscription),
+		areaSubscribers:    make(map[string][]Subscription),
+		sameSubscribers:    make(map[string][]Subscription),
 		recentMessages:     make(map[string][]RecentMessage),
 		autoSaveChan:       make(chan struct{}, 1),
 		stopAutoSave:       make(chan struct{}),
@@ -78,23 +155,33 @@ func (sm *SubscriptionManager) Load() error {
 	}
 
 	// Try to unmarshal
-	var subscriptions map[string][]Subscription
-	if err := json.Unmarshal(data, &subscriptions); err != nil {
+	subscriptions, err := unmarshalSubscriptions(data)
+	if err != nil {
 		// File is corrupted, try to recover by loading backup
 		return sm.loadBackup(err)
 	}
 
-	sm.stationSubscribers = subscriptions
+	sm.applySubscriptionData(subscriptions)
 
 	// Count total subscriptions
 	totalSubs := 0
-	for _, subs := range subscriptions {
+	for _, subs := range subscriptions.Stations {
+		totalSubs += len(subs)
+	}
+	for _, subs := range subscriptions.Areas {
+		totalSubs += len(subs)
+	}
+	totalSubs += len(subscriptions.Points)
+	for _, subs := range subscriptions.Same {
 		totalSubs += len(subs)
 	}
 
 	log.Info().
 		Str("file", sm.filePath).
-		Int("stations", len(subscriptions)).
+		Int("stations", len(subscriptions.Stations)).
+		Int("areas", len(subscriptions.Areas)).
+		Int("points", len(subscriptions.Points)).
+		Int("same", len(subscriptions.Same)).
 		Int("total_subscriptions", totalSubs).
 		Msg("Loaded subscriptions from file")
 
@@ -113,8 +200,8 @@ func (sm *SubscriptionManager) loadBackup(originalErr error) error {
 		return nil // Start fresh if backup also doesn't exist
 	}
 
-	var subscriptions map[string][]Subscription
-	if err := json.Unmarshal(data, &subscriptions); err != nil {
+	subscriptions, err := unmarshalSubscriptions(data)
+	if err != nil {
 		log.Error().
 			Err(originalErr).
 			Str("file", sm.filePath).
@@ -122,17 +209,57 @@ func (sm *SubscriptionManager) loadBackup(originalErr error) error {
 		return nil // Start fresh if backup is also corrupted
 	}
 
-	sm.stationSubscribers = subscriptions
+	sm.applySubscriptionData(subscriptions)
 	log.Warn().
 		Err(originalErr).
 		Str("file", sm.filePath).
 		Str("backup_file", backupPath).
-		Int("stations", len(subscriptions)).
+		Int("stations", len(subscriptions.Stations)).
+		Int("areas", len(subscriptions.Areas)).
+		Int("points", len(subscriptions.Points)).
+		Int("same", len(subscriptions.Same)).
 		Msg("Loaded subscriptions from backup after main file corruption")
 
 	return nil
 }
 
+// unmarshalSubscriptions decodes the subscription file, accepting the legacy
+// format which was a plain map of station code to subscriptions
+func unmarshalSubscriptions(data []byte) (*subscriptionData, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var subscriptions subscriptionData
+	if _, ok := raw["Stations"]; ok {
+		if err := json.Unmarshal(data, &subscriptions); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &subscriptions.Stations); err != nil {
+		return nil, err
+	}
+
+	return &subscriptions, nil
+}
+
+// applySubscriptionData replaces the in-memory subscriptions. Must hold sm.mu.
+func (sm *SubscriptionManager) applySubscriptionData(subscriptions *subscriptionData) {
+	sm.stationSubscribers = subscriptions.Stations
+	if sm.stationSubscribers == nil {
+		sm.stationSubscribers = make(map[string][]Subscription)
+	}
+	sm.areaSubscribers = subscriptions.Areas
+	if sm.areaSubscribers == nil {
+		sm.areaSubscribers = make(map[string][]Subscription)
+	}
+	sm.pointSubscribers = subscriptions.Points
+	sm.sameSubscribers = subscriptions.Same
+	if sm.sameSubscribers == nil {
+		sm.sameSubscribers = make(map[string][]Subscription)
+	}
+}
+
 // Save writes subscriptions to disk atomically
 func (sm *SubscriptionManager) Save() error {
 	sm.mu.RLock()
@@ -143,7 +270,12 @@ func (sm *SubscriptionManager) Save() error {
 	}
 
 	// Marshal the subscriptions
-	data, err := json.MarshalIndent(sm.stationSubscribers, "", "  ")
+	data, err := json.MarshalIndent(subscriptionData{
+		Stations: sm.stationSubscribers,
+		Areas:    sm.areaSubscribers,
+		Points:   sm.pointSubscribers,
+		Same:     sm.sameSubscribers,
+	}, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal subscriptions: %w", err)
 	}
@@ -157,7 +289,7 @@ func (sm *SubscriptionManager) Save() error {
 	// Create backup of existing file before overwriting
 	if _, err := os.Stat(sm.filePath); err == nil {
 		backupPath := sm.filePath + ".backup"
-		if err := copyFile(sm.filePath, backupPath); err != nil {
+		if err := CopyFile(sm.filePath, backupPath); err != nil {
 			log.Warn().Err(err).Msg("Failed to create backup, continuing with save")
 		}
 	}
@@ -177,8 +309,8 @@ func (sm *SubscriptionManager) Save() error {
 	return nil
 }
 
-// copyFile creates a copy of a file
-func copyFile(src, dst string) error {
+// CopyFile creates a copy of a file
+func CopyFile(src, dst string) error {
 	data, err := os.ReadFile(src)
 	if err != nil {
 		return err
@@ -225,6 +357,18 @@ func (sm *SubscriptionManager) Close() error {
 	return sm.Save()
 }
 
+// ValidateAreaCode validates a UGC code of the expected type (C for county, Z for zone)
+func ValidateAreaCode(code, ugcType string) (nwwsio.UGCCode, error) {
+	ugc, err := nwwsio.ParseUGCCode(code)
+	if err != nil {
+		return nwwsio.UGCCode{}, fmt.Errorf("must be a 6 character UGC code (e.g., MI%s063)", ugcType)
+	}
+	if ugc.Type != ugcType {
+		return nwwsio.UGCCode{}, fmt.Errorf("third character must be %s (e.g., MI%s063)", ugcType, ugcType)
+	}
+	return ugc, nil
+}
+
 func ValidateStationCode(code string) error {
 	code = strings.ToUpper(code)
 	if len(code) != 4 {
@@ -236,102 +380,293 @@ func ValidateStationCode(code string) error {
 	return nil
 }
 
-func (sm *SubscriptionManager) SubscribeToStation(userID, stationCode string, filters []string) {
+func (sm *SubscriptionManager) SubscribeToStation(stationCode string, sub Subscription) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	stationCode = strings.ToUpper(stationCode)
+	addSubscription(sm.stationSubscribers, strings.ToUpper(stationCode), sub)
+	sm.triggerAutoSave()
+}
 
-	// Default to "cap" if no filters provided
-	if len(filters) == 0 {
-		filters = []string{"cap"}
+func (sm *SubscriptionManager) UnsubscribeFromStation(target Subscriber, stationCode string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if removeSubscription(sm.stationSubscribers, strings.ToUpper(stationCode), target) {
+		sm.triggerAutoSave()
+		return true
 	}
+	return false
+}
 
-	// Normalize filter values to lowercase
-	normalizedFilters := make([]string, len(filters))
-	for i, f := range filters {
-		normalizedFilters[i] = strings.ToLower(f)
+func (sm *SubscriptionManager) GetStationSubscriptions(stationCode string) []Subscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return copySubscriptions(sm.stationSubscribers[strings.ToUpper(stationCode)])
+}
+
+// GetUserStationSubscriptions returns the stations a subscriber is subscribed to
+func (sm *SubscriptionManager) GetUserStationSubscriptions(target Subscriber) []UserSubscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return userSubscriptionKeys(sm.stationSubscribers, target)
+}
+
+// SubscribeToArea subscribes to a UGC county or zone code
+func (sm *SubscriptionManager) SubscribeToArea(code nwwsio.UGCCode, sub Subscription) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	addSubscription(sm.areaSubscribers, code.String(), sub)
+	sm.triggerAutoSave()
+}
+
+// UnsubscribeFromArea removes a subscription to a UGC county or zone code
+func (sm *SubscriptionManager) UnsubscribeFromArea(target Subscriber, code nwwsio.UGCCode) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if removeSubscription(sm.areaSubscribers, code.String(), target) {
+		sm.triggerAutoSave()
+		return true
 	}
+	return false
+}
 
-	// Remove existing subscription if present
-	subs := sm.stationSubscribers[stationCode]
-	for i, sub := range subs {
-		if sub.UserID == userID {
-			sm.stationSubscribers[stationCode] = append(subs[:i], subs[i+1:]...)
-			break
+// GetAreaSubscriptions returns the subscriptions matching any of the given UGC
+// codes, including statewide (000) subscriptions. A subscriber subscribed to
+// several of the codes appears once per code.
+func (sm *SubscriptionManager) GetAreaSubscriptions(codes []nwwsio.UGCCode) []Subscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var result []Subscription
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		statewide := nwwsio.UGCCode{State: code.State, Type: code.Type}
+		for _, key := range []string{code.String(), statewide.String()} {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, sm.areaSubscribers[key]...)
 		}
 	}
+	return copySubscriptions(result)
+}
 
-	// Add new subscription with filters
-	sm.stationSubscribers[stationCode] = append(sm.stationSubscribers[stationCode], Subscription{
-		UserID:  userID,
-		Filters: normalizedFilters,
+// GetUserAreaSubscriptions returns the UGC codes a subscriber is subscribed to
+func (sm *SubscriptionManager) GetUserAreaSubscriptions(target Subscriber) []UserSubscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return userSubscriptionKeys(sm.areaSubscribers, target)
+}
+
+// SubscribeToPoint subscribes to warnings containing a location, replacing
+// any existing location with the same label
+func (sm *SubscriptionManager) SubscribeToPoint(label string, location nwwsio.Point, sub Subscription) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.removePoint(sub.Subscriber, label)
+
+	sub.Filters = normalizeFilters(sub.Filters)
+	sm.pointSubscribers = append(sm.pointSubscribers, PointSubscription{
+		Subscription: sub,
+		Label:        label,
+		Location:     location,
 	})
 
 	sm.triggerAutoSave()
 }
 
-func (sm *SubscriptionManager) UnsubscribeFromStation(userID, stationCode string) bool {
+// UnsubscribeFromPoint removes a saved location by label
+func (sm *SubscriptionManager) UnsubscribeFromPoint(target Subscriber, label string) bool {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	stationCode = strings.ToUpper(stationCode)
-
-	subscribers := sm.stationSubscribers[stationCode]
-	for i, sub := range subscribers {
-		if sub.UserID == userID {
-			sm.stationSubscribers[stationCode] = append(subscribers[:i], subscribers[i+1:]...)
-			if len(sm.stationSubscribers[stationCode]) == 0 {
-				delete(sm.stationSubscribers, stationCode)
-			}
+	if sm.removePoint(target, label) {
+		sm.triggerAutoSave()
+		return true
+	}
+	return false
+}
 
-			sm.triggerAutoSave()
+// removePoint removes a saved location by label. Must hold sm.mu.
+func (sm *SubscriptionManager) removePoint(target Subscriber, label string) bool {
+	for i, sub := range sm.pointSubscribers {
+		if sub.Subscriber == target && strings.EqualFold(sub.Label, label) {
+			sm.pointSubscribers = append(sm.pointSubscribers[:i], sm.pointSubscribers[i+1:]...)
 			return true
 		}
 	}
 	return false
 }
 
-func (sm *SubscriptionManager) GetStationSubscriptions(stationCode string) []Subscription {
+// GetPointSubscriptions returns the point subscriptions whose location is
+// inside any of the given polygons
+func (sm *SubscriptionManager) GetPointSubscriptions(polygons []nwwsio.Polygon) []PointSubscription {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	stationCode = strings.ToUpper(stationCode)
-	subscriptions := sm.stationSubscribers[stationCode]
+	var result []PointSubscription
+	for _, sub := range sm.pointSubscribers {
+		for _, polygon := range polygons {
+			if polygon.Contains(sub.Location) {
+				result = append(result, sub)
+				break
+			}
+		}
+	}
+	return result
+}
 
-	result := make([]Subscription, len(subscriptions))
-	copy(result, subscriptions)
+// GetUserPointSubscriptions returns a subscriber's saved locations
+func (sm *SubscriptionManager) GetUserPointSubscriptions(target Subscriber) []PointSubscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var result []PointSubscription
+	for _, sub := range sm.pointSubscribers {
+		if sub.Subscriber == target {
+			result = append(result, sub)
+		}
+	}
 	return result
 }
 
-func (sm *SubscriptionManager) GetUserStationSubscriptions(userID string) []string {
+// SubscribeToSAME subscribes to a SAME location code
+func (sm *SubscriptionManager) SubscribeToSAME(code nwwsio.SAMECode, sub Subscription) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	addSubscription(sm.sameSubscribers, code.String(), sub)
+	sm.triggerAutoSave()
+}
+
+// UnsubscribeFromSAME removes a subscription to a SAME location code
+func (sm *SubscriptionManager) UnsubscribeFromSAME(target Subscriber, code nwwsio.SAMECode) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if removeSubscription(sm.sameSubscribers, code.String(), target) {
+		sm.triggerAutoSave()
+		return true
+	}
+	return false
+}
+
+// GetSAMESubscriptions returns the subscriptions whose SAME code matches any
+// of the given codes, following weather radio partial county and statewide rules
+func (sm *SubscriptionManager) GetSAMESubscriptions(codes []nwwsio.SAMECode) []Subscription {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	var stations []string
-	for station, subscriptions := range sm.stationSubscribers {
-		for _, sub := range subscriptions {
-			if sub.UserID == userID {
-				stations = append(stations, station)
+	var result []Subscription
+	for key, subs := range sm.sameSubscribers {
+		subscribed, err := nwwsio.ParseSAMECode(key)
+		if err != nil {
+			continue
+		}
+		for _, code := range codes {
+			if subscribed.Matches(code) {
+				result = append(result, subs...)
 				break
 			}
 		}
 	}
-	return stations
+	return copySubscriptions(result)
 }
 
-func (sm *SubscriptionManager) UnsubscribeFromAll(userID string) int {
+// GetUserSAMESubscriptions returns the SAME codes a subscriber is subscribed to
+func (sm *SubscriptionManager) GetUserSAMESubscriptions(target Subscriber) []UserSubscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return userSubscriptionKeys(sm.sameSubscribers, target)
+}
+
+func (sm *SubscriptionManager) UnsubscribeFromAll(target Subscriber) int {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	count := removeUserSubscriptions(sm.stationSubscribers, target)
+	count += removeUserSubscriptions(sm.areaSubscribers, target)
+	count += removeUserSubscriptions(sm.sameSubscribers, target)
+
+	points := make([]PointSubscription, 0, len(sm.pointSubscribers))
+	for _, sub := range sm.pointSubscribers {
+		if sub.Subscriber == target {
+			count++
+		} else {
+			points = append(points, sub)
+		}
+	}
+	sm.pointSubscribers = points
+
+	if count > 0 {
+		sm.triggerAutoSave()
+	}
+
+	return count
+}
+
+// addSubscription adds or replaces a subscriber's subscription under key
+func addSubscription(subscribers map[string][]Subscription, key string, newSub Subscription) {
+	// Remove existing subscription if present
+	subs := subscribers[key]
+	for i, sub := range subs {
+		if sub.Subscriber == newSub.Subscriber {
+			subscribers[key] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	// Add new subscription with filters
+	newSub.Filters = normalizeFilters(newSub.Filters)
+	subscribers[key] = append(subscribers[key], newSub)
+}
+
+// normalizeFilters lowercases filters, defaulting to "cap" if none are provided
+func normalizeFilters(filters []string) []string {
+	if len(filters) == 0 {
+		return []string{"cap"}
+	}
+
+	normalizedFilters := make([]string, len(filters))
+	for i, f := range filters {
+		normalizedFilters[i] = strings.ToLower(f)
+	}
+	return normalizedFilters
+}
+
+// removeSubscription removes a subscriber's subscription under key, returning whether one existed
+func removeSubscription(subscribers map[string][]Subscription, key string, target Subscriber) bool {
+	subs := subscribers[key]
+	for i, sub := range subs {
+		if sub.Subscriber == target {
+			subscribers[key] = append(subs[:i], subs[i+1:]...)
+			if len(subscribers[key]) == 0 {
+				delete(subscribers, key)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// removeUserSubscriptions removes every subscription for a subscriber, returning the count removed
+func removeUserSubscriptions(subscribers map[string][]Subscription, target Subscriber) int {
 	count := 0
 
-	for station := range sm.stationSubscribers {
-		subscriptions := sm.stationSubscribers[station]
-		newSubs := make([]Subscription, 0, len(subscriptions))
+	for key, subs := range subscribers {
+		newSubs := make([]Subscription, 0, len(subs))
 
-		for _, sub := range subscriptions {
-			if sub.UserID == userID {
+		for _, sub := range subs {
+			if sub.Subscriber == target {
 				count++
 			} else {
 				newSubs = append(newSubs, sub)
@@ -339,32 +674,45 @@ func (sm *SubscriptionManager) UnsubscribeFromAll(userID string) int {
 		}
 
 		if len(newSubs) == 0 {
-			delete(sm.stationSubscribers, station)
-		} else if len(newSubs) != len(subscriptions) {
-			sm.stationSubscribers[station] = newSubs
+			delete(subscribers, key)
+		} else if len(newSubs) != len(subs) {
+			subscribers[key] = newSubs
 		}
 	}
 
-	if count > 0 {
-		sm.triggerAutoSave()
+	return count
+}
+
+// userSubscriptionKeys returns a subscriber's subscriptions sorted by key
+func userSubscriptionKeys(subscribers map[string][]Subscription, target Subscriber) []UserSubscription {
+	var result []UserSubscription
+	for key, subs := range subscribers {
+		for _, sub := range subs {
+			if sub.Subscriber == target {
+				result = append(result, UserSubscription{Key: key, Filters: append([]string(nil), sub.Filters...)})
+				break
+			}
+		}
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
 
-	return count
+func copySubscriptions(subscriptions []Subscription) []Subscription {
+	result := make([]Subscription, len(subscriptions))
+	copy(result, subscriptions)
+	return result
 }
 
-func (sm *SubscriptionManager) AddRecentMessage(msg RecentMessage) {
+// AddRecentMessage records a product in its station's recent history. If it
+// supersedes an earlier version, that version is replaced and returned.
+func (sm *SubscriptionManager) AddRecentMessage(msg RecentMessage) (superseded RecentMessage, found bool) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	station := strings.ToUpper(msg.Station)
-	messages := sm.recentMessages[station]
-
-	messages = append(messages, msg)
-	if len(messages) > MaxRecentMessages {
-		messages = messages[1:]
-	}
-
-	sm.recentMessages[station] = messages
+	sm.recentMessages[station], superseded, found = appendRecentMessage(sm.recentMessages[station], msg)
+	return superseded, found
 }
 
 func (sm *SubscriptionManager) GetRecentMessages(stationCode string) []RecentMessage {
@@ -379,7 +727,23 @@ func (sm *SubscriptionManager) GetRecentMessages(stationCode string) []RecentMes
 	return result
 }
 
-// ValidateFilters validates that all provided filters are either special filters or known product categories
+// FindRecentMessage returns the recent message with the given NWWS ID
+func (sm *SubscriptionManager) FindRecentMessage(id string) (RecentMessage, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	for _, messages := range sm.recentMessages {
+		for _, msg := range messages {
+			if msg.ID == id {
+				return msg, true
+			}
+		}
+	}
+	return RecentMessage{}, false
+}
+
+// ValidateFilters validates that all provided filters are special filters,
+// known product categories or well formed VTEC, pil and constraint filters
 func ValidateFilters(filters []string) (invalidFilters []string) {
 	if len(filters) == 0 {
 		return nil
@@ -389,6 +753,7 @@ func ValidateFilters(filters []string) (invalidFilters []string) {
 	validFilters := make(map[string]bool)
 	validFilters["all"] = true
 	validFilters["cap"] = true
+	validFilters["emergency"] = true
 
 	// Add all known product categories (case-insensitive)
 	for _, category := range nwwsio.GetAllCategories() {
@@ -398,6 +763,18 @@ func ValidateFilters(filters []string) (invalidFilters []string) {
 	// Check each provided filter
 	for _, filter := range filters {
 		normalized := strings.ToLower(strings.TrimSpace(filter))
+		if _, ok, err := parseConstraint(normalized); ok {
+			if err != nil {
+				invalidFilters = append(invalidFilters, filter)
+			}
+			continue
+		}
+		if _, ok, err := parseSelector(normalized); ok {
+			if err != nil {
+				invalidFilters = append(invalidFilters, filter)
+			}
+			continue
+		}
 		if !validFilters[normalized] {
 			invalidFilters = append(invalidFilters, filter)
 		}
@@ -408,7 +785,7 @@ func ValidateFilters(filters []string) (invalidFilters []string) {
 
 // GetValidFilters returns a sorted list of all valid filter options
 func GetValidFilters() []string {
-	filters := []string{"all", "cap"}
+	filters := []string{"all", "cap", "emergency"}
 	categories := nwwsio.GetAllCategories()
 	sort.Strings(categories)
 	return append(filters, categories...)
@@ -0,0 +1,376 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gosrc.io/xmpp"
+	"gosrc.io/xmpp/stanza"
+)
+
+const (
+	// Reconnect delays grow exponentially from the base delay up to the max
+	ReconnectBaseDelay = time.Second
+	ReconnectMaxDelay  = 2 * time.Minute
+	// A connection that stays up this long resets the backoff
+	ConnectionStableAfter = time.Minute
+	// Number of MUC join attempts before the connection is abandoned
+	MUCJoinAttempts = 3
+	// Number of connection state transitions kept per supervisor
+	MaxConnectionHistory = 50
+	// Number of transitions listed per site by the status command
+	MaxTransitionsShown = 5
+)
+
+// ConnectionState is the state of a supervised NWWS-OI connection
+type ConnectionState string
+
+const (
+	ConnStateConnecting   ConnectionState = "connecting"
+	ConnStateConnected    ConnectionState = "connected"
+	ConnStateJoined       ConnectionState = "joined"
+	ConnStateDisconnected ConnectionState = "disconnected"
+	ConnStateFailed       ConnectionState = "failed"
+	ConnStateStopped      ConnectionState = "stopped"
+)
+
+// ConnectionTransition records a change in connection state
+type ConnectionTransition struct {
+	Time   time.Time
+	Site   string
+	Server string
+	State  ConnectionState
+	Reason string `json:",omitempty"`
+}
+
+// String returns a short description such as "15:04:05Z cprk joined nwws-oi-cprk.weather.gov:5222"
+func (t ConnectionTransition) String() string {
+	msg := fmt.Sprintf("%s %s %s %s", t.Time.UTC().Format("15:04:05Z"), t.Site, t.State, t.Server)
+	if t.Reason != "" {
+		msg += fmt.Sprintf(" (%s)", t.Reason)
+	}
+	return msg
+}
+
+// ConnectionSupervisor keeps a single NWWS-OI connection alive. It rotates
+// through its servers as connections fail, backing off exponentially with
+// jitter, and joins the MUC after every connect without exiting on failure.
+type ConnectionSupervisor struct {
+	name    string
+	servers []string // host:port, in order of preference
+	config  xmpp.Config
+	mucJID  *stanza.Jid
+	router  *xmpp.Router
+
+	mu        sync.Mutex
+	state     ConnectionState
+	server    string
+	since     time.Time // when the current state was entered
+	client    *xmpp.Client
+	history   []ConnectionTransition // oldest first
+	reconnect chan string            // forced reconnect requests
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// newConnectionSupervisor creates a supervisor which passes received products to the handler
+func newConnectionSupervisor(name string, servers []string, config xmpp.Config, mucJID *stanza.Jid, handler ProductHandler) *ConnectionSupervisor {
+	router := xmpp.NewRouter()
+	router.HandleFunc("message", func(s xmpp.Sender, p stanza.Packet) {
+		handleMessage(s, p, handler, name)
+	})
+	router.HandleFunc("presence", func(s xmpp.Sender, p stanza.Packet) {
+		handlePresence(s, p, mucJID)
+	})
+	router.NewRoute().IQNamespaces("jabber:iq:version").HandlerFunc(handleVersion)
+
+	return &ConnectionSupervisor{
+		name:      name,
+		servers:   servers,
+		config:    config,
+		mucJID:    mucJID,
+		router:    router,
+		state:     ConnStateDisconnected,
+		reconnect: make(chan string, 1),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Run connects and keeps reconnecting until Stop is called or a permanent
+// error such as an authentication failure occurs
+func (cs *ConnectionSupervisor) Run() error {
+	attempt := 0
+	for index := 0; ; index = (index + 1) % len(cs.servers) {
+		if cs.stopped() {
+			return nil
+		}
+
+		server := cs.servers[index]
+		lost, err := cs.connect(server)
+		if err != nil {
+			if cs.stopped() {
+				return nil
+			}
+			// Retrying bad credentials would only get the account locked
+			if isAuthFailure(err) {
+				cs.transition(ConnStateFailed, server, err.Error())
+				return fmt.Errorf("unrecoverable NWWS-IO connection error on %s: %w", server, err)
+			}
+
+			cs.transition(ConnStateFailed, server, err.Error())
+			delay := backoffDelay(attempt)
+			attempt++
+			log.Warn().
+				Err(err).
+				Str("site", cs.name).
+				Str("failed_server", server).
+				Str("next_server", cs.servers[(index+1)%len(cs.servers)]).
+				Dur("delay", delay).
+				Msg("Failed to connect to NWWS-IO server, retrying")
+			if !cs.sleep(delay) {
+				return nil
+			}
+			continue
+		}
+
+		connectedAt := time.Now()
+		reason := cs.wait(lost)
+		if cs.stopped() {
+			return nil
+		}
+		cs.disconnect(server, reason)
+
+		// Flapping connections back off like failed ones
+		if time.Since(connectedAt) >= ConnectionStableAfter {
+			attempt = 0
+		} else {
+			delay := backoffDelay(attempt)
+			attempt++
+			if !cs.sleep(delay) {
+				return nil
+			}
+		}
+	}
+}
+
+// connect establishes a session with a server and joins the MUC. The returned
+// channel receives a reason when the connection is lost.
+func (cs *ConnectionSupervisor) connect(server string) (<-chan string, error) {
+	cs.transition(ConnStateConnecting, server, "")
+
+	// The client only reports errors when its receive loop exits, so any
+	// error after the session is established means the connection was lost
+	lost := make(chan string, 1)
+	config := cs.config
+	config.Address = server
+	client, err := xmpp.NewClient(&config, cs.router, func(err error) {
+		mucErrorHandler(err, cs.mucJID)
+		select {
+		case lost <- err.Error():
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Connect(); err != nil {
+		_ = client.Disconnect()
+		return nil, err
+	}
+
+	// Stop may have been called while connecting
+	cs.mu.Lock()
+	if cs.stopped() {
+		cs.mu.Unlock()
+		_ = client.Disconnect()
+		return nil, errors.New("supervisor stopped")
+	}
+	cs.client = client
+	cs.mu.Unlock()
+	cs.transition(ConnStateConnected, server, "")
+	log.Info().Str("site", cs.name).Str("server", server).Msg("NWWS-IO connection established")
+
+	if err := cs.joinMUC(client); err != nil {
+		cs.disconnect(server, err.Error())
+		return nil, err
+	}
+	cs.transition(ConnStateJoined, server, "")
+	log.Info().Str("site", cs.name).Str("jid", cs.mucJID.Full()).Msg("Successfully joined Multi-user Chat - ready to receive messages")
+
+	return lost, nil
+}
+
+// joinMUC joins the MUC, retrying a few times before giving up on the connection
+func (cs *ConnectionSupervisor) joinMUC(client *xmpp.Client) error {
+	var err error
+	for i := 0; i < MUCJoinAttempts; i++ {
+		if err = joinMUC(client, cs.mucJID); err == nil {
+			return nil
+		}
+		log.Warn().Err(err).Str("site", cs.name).Int("attempt", i+1).Msg("Failed to join Multi-user Chat")
+		if !cs.sleep(MUCReconnectDelay) {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to join Multi-user Chat after %d attempts: %w", MUCJoinAttempts, err)
+}
+
+// wait blocks until the connection is lost, a reconnect is requested or the supervisor stops
+func (cs *ConnectionSupervisor) wait(lost <-chan string) string {
+	select {
+	case reason := <-lost:
+		return reason
+	case reason := <-cs.reconnect:
+		return reason
+	case <-cs.stop:
+		return "stopped"
+	}
+}
+
+// disconnect closes the current connection
+func (cs *ConnectionSupervisor) disconnect(server, reason string) {
+	cs.mu.Lock()
+	client := cs.client
+	cs.client = nil
+	cs.mu.Unlock()
+
+	if client != nil {
+		_ = client.Disconnect()
+	}
+	cs.transition(ConnStateDisconnected, server, reason)
+	log.Warn().Str("site", cs.name).Str("server", server).Str("reason", reason).Msg("NWWS-IO connection closed")
+}
+
+// Reconnect drops the current connection and moves on to the next server
+func (cs *ConnectionSupervisor) Reconnect(reason string) {
+	select {
+	case cs.reconnect <- reason:
+	default:
+	}
+}
+
+// Stop leaves the MUC, disconnects and stops the supervisor
+func (cs *ConnectionSupervisor) Stop() {
+	cs.stopOnce.Do(func() {
+		close(cs.stop)
+
+		cs.mu.Lock()
+		client := cs.client
+		server := cs.server
+		cs.client = nil
+		cs.mu.Unlock()
+
+		if client != nil {
+			err := client.Send(stanza.Presence{
+				Attrs: stanza.Attrs{
+					To:   cs.mucJID.Full(),
+					Type: stanza.PresenceTypeUnavailable,
+				},
+			})
+			if err != nil {
+				log.Error().Err(err).Str("site", cs.name).Msg("Failed to send presence unavailable")
+			}
+			_ = client.Disconnect()
+		}
+		cs.transition(ConnStateStopped, server, "")
+	})
+}
+
+func (cs *ConnectionSupervisor) stopped() bool {
+	select {
+	case <-cs.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for the delay, returning false if the supervisor stopped first
+func (cs *ConnectionSupervisor) sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-cs.stop:
+		return false
+	}
+}
+
+// transition records a state change
+func (cs *ConnectionSupervisor) transition(state ConnectionState, server, reason string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.state = state
+	cs.server = server
+	cs.since = time.Now()
+	switch state {
+	case ConnStateFailed:
+		metricConnectionFailures.Add(1)
+	case ConnStateDisconnected:
+		metricDisconnects.Add(1)
+	}
+	cs.history = append(cs.history, ConnectionTransition{
+		Time:   cs.since,
+		Site:   cs.name,
+		Server: server,
+		State:  state,
+		Reason: reason,
+	})
+	if len(cs.history) > MaxConnectionHistory {
+		cs.history = cs.history[1:]
+	}
+}
+
+// State returns the current state and the server it applies to
+func (cs *ConnectionSupervisor) State() (ConnectionState, string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.state, cs.server
+}
+
+// JoinedSince returns when the MUC was joined, or false if it isn't currently joined
+func (cs *ConnectionSupervisor) JoinedSince() (time.Time, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.state != ConnStateJoined {
+		return time.Time{}, false
+	}
+	return cs.since, true
+}
+
+// History returns the recorded state transitions, oldest first
+func (cs *ConnectionSupervisor) History() []ConnectionTransition {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	result := make([]ConnectionTransition, len(cs.history))
+	copy(result, cs.history)
+	return result
+}
+
+// isAuthFailure reports whether a connection error was the server rejecting
+// our credentials. The XMPP library marks most connection errors, including
+// refused connections, as permanent so its flag can't be relied on.
+func isAuthFailure(err error) bool {
+	return strings.Contains(err.Error(), "auth failure")
+}
+
+// backoffDelay returns the delay before a reconnect attempt: exponential in
+// the attempt number, capped, with up to half of it randomized
+func backoffDelay(attempt int) time.Duration {
+	delay := ReconnectMaxDelay
+	if attempt < 16 {
+		delay = min(ReconnectBaseDelay<<attempt, ReconnectMaxDelay)
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+// NWWS-OI sends products continually, including KPA keep-alives, so a joined
+// connection that stays silent this long is assumed to be half dead
+const DefaultStallThreshold = 5 * time.Minute
+
+// StreamOutage is a period in which a site delivered no products
+type StreamOutage struct {
+	Site  string
+	Start time.Time // when the last product before the outage arrived
+	End   time.Time // when products resumed, zero while ongoing
+}
+
+// Duration returns the length of the outage, up to now if it is ongoing
+func (o StreamOutage) Duration() time.Duration {
+	end := o.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(o.Start).Round(time.Second)
+}
+
+type siteActivity struct {
+	lastProduct   time.Time
+	lastKeepAlive time.Time
+	outage        *StreamOutage // ongoing outage, nil while healthy
+}
+
+// Watchdog tracks how recently each site delivered a product or keep-alive
+// so silent connections can be detected
+type Watchdog struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	sites     map[string]*siteActivity
+}
+
+func NewWatchdog(threshold time.Duration) *Watchdog {
+	if threshold <= 0 {
+		threshold = DefaultStallThreshold
+	}
+	return &Watchdog{
+		threshold: threshold,
+		sites:     make(map[string]*siteActivity),
+	}
+}
+
+// activity returns the record for a site, creating it if needed. Must hold w.mu.
+func (w *Watchdog) activity(site string) *siteActivity {
+	activity, exists := w.sites[site]
+	if !exists {
+		activity = &siteActivity{}
+		w.sites[site] = activity
+	}
+	return activity
+}
+
+// Observe records a product received from a site. If the site was stalled
+// the finished outage is returned.
+func (w *Watchdog) Observe(site string, keepAlive bool, now time.Time) *StreamOutage {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	activity := w.activity(site)
+	activity.lastProduct = now
+	if keepAlive {
+		activity.lastKeepAlive = now
+	}
+
+	if activity.outage == nil {
+		return nil
+	}
+	outage := *activity.outage
+	outage.End = now
+	activity.outage = nil
+	return &outage
+}
+
+// Check reports whether a site joined since joinedAt has been silent for
+// longer than the threshold. The outage is returned the first time the stall
+// is detected, later checks only report that it is still stalled.
+func (w *Watchdog) Check(site string, joinedAt, now time.Time) (stalled bool, started *StreamOutage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	activity := w.activity(site)
+
+	// Silence is measured from whichever is later, the last product or the
+	// last time the MUC was joined
+	lastHeard := activity.lastProduct
+	if joinedAt.After(lastHeard) {
+		lastHeard = joinedAt
+	}
+	if now.Sub(lastHeard) < w.threshold {
+		return false, nil
+	}
+
+	if activity.outage != nil {
+		return true, nil
+	}
+	start := activity.lastProduct
+	if start.IsZero() {
+		start = joinedAt
+	}
+	activity.outage = &StreamOutage{Site: site, Start: start}
+	outage := *activity.outage
+	return true, &outage
+}
+
+// LastHeard returns when a site last delivered a product and a keep-alive
+func (w *Watchdog) LastHeard(site string) (lastProduct, lastKeepAlive time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	activity := w.activity(site)
+	return activity.lastProduct, activity.lastKeepAlive
+}
+
+// isKeepAlive reports whether a product is an NWWS-OI keep-alive message
+func isKeepAlive(messageNWWSIOX *nwwsio.NWWSOIMessageXExtension) bool {
+	return strings.HasPrefix(messageNWWSIOX.AwipsID, "KPA")
+}
+
+// runWatchdog periodically checks every joined site for a stalled stream,
+// forcing a reconnect and alerting the admin channel when one is found
+func (c *SeabirdClient) runWatchdog(ctx context.Context) {
+	interval := max(c.watchdog.threshold/4, 15*time.Second)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, site := range c.sites {
+				joinedAt, joined := site.JoinedSince()
+				if !joined {
+					continue
+				}
+
+				stalled, outage := c.watchdog.Check(site.name, joinedAt, now)
+				if !stalled {
+					continue
+				}
+
+				lastProduct, lastKeepAlive := c.watchdog.LastHeard(site.name)
+				log.Warn().
+					Str("site", site.name).
+					Time("last_product", lastProduct).
+					Time("last_keepalive", lastKeepAlive).
+					Msg("NWWS-IO stream stalled - forcing reconnect")
+				site.Reconnect("stream stalled")
+
+				if outage != nil {
+					c.alertAdmins(fmt.Sprintf("NWWS-OI site %s has been silent since %s (%s), forcing a reconnect. Last keep-alive: %s",
+						site.name,
+						formatWatchdogTime(outage.Start),
+						outage.Duration(),
+						formatWatchdogTime(lastKeepAlive)))
+				}
+			}
+		}
+	}
+}
+
+// observeActivity feeds a received product to the watchdog, alerting the
+// admin channel when it ends an outage
+func (c *SeabirdClient) observeActivity(site string, messageNWWSIOX *nwwsio.NWWSOIMessageXExtension, received time.Time) {
+	outage := c.watchdog.Observe(site, isKeepAlive(messageNWWSIOX), received)
+	if outage == nil {
+		return
+	}
+
+	log.Info().
+		Str("site", site).
+		Time("outage_start", outage.Start).
+		Time("outage_end", outage.End).
+		Dur("outage_duration", outage.Duration()).
+		Msg("NWWS-IO stream resumed")
+	c.alertAdmins(fmt.Sprintf("NWWS-OI site %s resumed. Outage window: %s to %s (%s)",
+		site,
+		formatWatchdogTime(outage.Start),
+		formatWatchdogTime(outage.End),
+		outage.Duration()))
+}
+
+// alertAdmins posts a message to the admin channel if one is configured
+func (c *SeabirdClient) alertAdmins(msg string) {
+	if c.adminChannel == "" {
+		return
+	}
+	c.SendMessage(c.adminChannel, msg)
+}
+
+func formatWatchdogTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format("Jan 2 15:04:05Z")
+}
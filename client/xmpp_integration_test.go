@@ -0,0 +1,383 @@
+package client
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const fakePassword = "hunter2"
+
+func TestMain(m *testing.M) {
+	// Rejoins are exercised against the fake server, don't wait seconds for them
+	MUCReconnectDelay = 50 * time.Millisecond
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// startIntegrationClient creates a client for the given fake servers and runs
+// its NWWS-OI connections until the test finishes. Each connection's exit
+// error is delivered on the returned channel.
+func startIntegrationClient(t *testing.T, config Config) (*SeabirdClient, <-chan error) {
+	t.Helper()
+
+	config.NWWSIOUsername = "testuser"
+	if config.NWWSIOPassword == "" {
+		config.NWWSIOPassword = fakePassword
+	}
+	config.ArchiveDir = t.TempDir()
+
+	client, err := NewSeabirdClient(config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	errs := make(chan error, len(client.sites))
+	for _, site := range client.sites {
+		// The fake server doesn't offer TLS
+		site.config.Insecure = true
+		go func() {
+			errs <- site.Run()
+		}()
+	}
+	t.Cleanup(func() {
+		_ = client.Shutdown()
+	})
+	return client, errs
+}
+
+// waitFor polls until cond is true, failing the test if it takes too long
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(fakeWaitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitForExit waits for a connection supervisor to exit, returning its error
+func waitForExit(t *testing.T, errs <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(fakeWaitTimeout):
+		t.Fatal("timed out waiting for the connection to exit")
+		return nil
+	}
+}
+
+// deadServerAddr returns an address nothing is listening on
+func deadServerAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	return addr
+}
+
+// isArchived reports whether a product has been archived
+func isArchived(client *SeabirdClient, id string) bool {
+	product, err := client.archive.Get(id)
+	return err == nil && product != nil
+}
+
+func hasTransition(history []ConnectionTransition, state ConnectionState, server string) bool {
+	for _, transition := range history {
+		if transition.State == state && transition.Server == server {
+			return true
+		}
+	}
+	return false
+}
+
+func tornadoWarning(id string) fakeProduct {
+	return fakeProduct{
+		ID:      id,
+		Cccc:    "KOUN",
+		Ttaaii:  "WFUS54",
+		Issue:   "2026-05-06T22:14:00Z",
+		AwipsID: "TOROUN",
+		Text: "\n\n288 \nWFUS54 KOUN 062214\nTOROUN\n\nOKC027-087-062245-\n" +
+			"/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/\n\n" +
+			"BULLETIN - EAS ACTIVATION REQUESTED\nTornado Warning\n" +
+			"National Weather Service Norman OK\n514 PM CDT Wed May 6 2026\n\n" +
+			"The National Weather Service in Norman has issued a\n\n" +
+			"* Tornado Warning for...\n  Cleveland County in central Oklahoma...\n\n" +
+			"LAT...LON 3500 9760 3540 9760 3540 9720 3500 9720\n\n$$\n",
+	}
+}
+
+func TestXMPPJoinAndReceiveProducts(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	client, _ := startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+
+	occupant := server.WaitForJoin()
+	if !strings.HasPrefix(occupant, fakeMUCRoom+"/testuser-") {
+		t.Errorf("unexpected occupant JID %q", occupant)
+	}
+	waitFor(t, "joined state", func() bool {
+		state, _ := client.sites[0].State()
+		return state == ConnStateJoined
+	})
+
+	if sent := server.SendProduct(tornadoWarning("1001.1")); sent != 1 {
+		t.Fatalf("product sent to %d occupants, want 1", sent)
+	}
+	waitFor(t, "product in archive", func() bool {
+		return isArchived(client, "1001.1")
+	})
+
+	product, err := client.archive.Get("1001.1")
+	if err != nil {
+		t.Fatalf("failed to read archived product: %v", err)
+	}
+	if product.Station != "KOUN" || product.AwipsID != "TOROUN" {
+		t.Errorf("archived product has station %q awips ID %q", product.Station, product.AwipsID)
+	}
+	if len(product.Events) != 1 {
+		t.Errorf("archived product has events %v, want 1 tornado warning", product.Events)
+	}
+	if _, found := client.subscriptions.FindRecentMessage("1001.1"); !found {
+		t.Error("product not recorded as a recent message")
+	}
+	if lastProduct, _ := client.watchdog.LastHeard("nwws"); lastProduct.IsZero() {
+		t.Error("watchdog did not observe the product")
+	}
+}
+
+func TestXMPPBadPasswordIsFatal(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	client, errs := startIntegrationClient(t, Config{
+		NWWSIOServers:  []string{server.Addr()},
+		NWWSIOPassword: "wrong",
+	})
+
+	err := waitForExit(t, errs)
+	if err == nil || !isAuthFailure(err) {
+		t.Fatalf("expected an auth failure, got %v", err)
+	}
+	if state, _ := client.sites[0].State(); state != ConnStateFailed {
+		t.Errorf("state is %s, want %s", state, ConnStateFailed)
+	}
+	if logins := server.Logins(); logins != 0 {
+		t.Errorf("server accepted %d logins", logins)
+	}
+}
+
+func TestXMPPDualSiteAuthFailureStopsRun(t *testing.T) {
+	t.Parallel()
+
+	core := newFakeSeabirdCore(t)
+	good := newFakeXMPPServer(t, fakePassword)
+	bad := newFakeXMPPServer(t, "other")
+	client, err := NewSeabirdClient(Config{
+		SeabirdCoreURL: core.URL(),
+		NWWSIOUsername: "testuser",
+		NWWSIOPassword: fakePassword,
+		NWWSIOServers:  []string{good.Addr(), bad.Addr()},
+		DualSite:       true,
+		ArchiveDir:     t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	for _, site := range client.sites {
+		site.config.Insecure = true
+	}
+	t.Cleanup(func() {
+		_ = client.Shutdown()
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Run()
+	}()
+
+	// The healthy site must not keep Run from returning
+	err = waitForExit(t, done)
+	if err == nil || !isAuthFailure(err) {
+		t.Fatalf("expected an auth failure, got %v", err)
+	}
+	if state, _ := client.sites[0].State(); state != ConnStateStopped {
+		t.Errorf("healthy site is %s, want %s", state, ConnStateStopped)
+	}
+}
+
+func TestXMPPReconnectAfterDisconnect(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	client, _ := startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+	server.WaitForJoin()
+
+	server.DropConnections()
+	server.WaitForJoin()
+
+	if logins := server.Logins(); logins != 2 {
+		t.Errorf("server saw %d logins, want 2", logins)
+	}
+	if !hasTransition(client.sites[0].History(), ConnStateDisconnected, server.Addr()) {
+		t.Error("disconnect not recorded in connection history")
+	}
+
+	server.SendProduct(tornadoWarning("1002.1"))
+	waitFor(t, "product after reconnect", func() bool {
+		return isArchived(client, "1002.1")
+	})
+}
+
+func TestXMPPFailoverToSecondServer(t *testing.T) {
+	t.Parallel()
+
+	dead := deadServerAddr(t)
+	server := newFakeXMPPServer(t, fakePassword)
+	client, _ := startIntegrationClient(t, Config{NWWSIOServers: []string{dead, server.Addr()}})
+
+	server.WaitForJoin()
+
+	history := client.sites[0].History()
+	if !hasTransition(history, ConnStateFailed, dead) {
+		t.Error("failed connection to the first server not recorded")
+	}
+	if !hasTransition(history, ConnStateJoined, server.Addr()) {
+		t.Error("join on the second server not recorded")
+	}
+}
+
+func TestXMPPRejoinAfterPresenceError(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	server.RejectJoins(1)
+	client, _ := startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+
+	// The rejected join is retried on the same connection
+	server.WaitForJoin()
+	if logins := server.Logins(); logins != 1 {
+		t.Errorf("server saw %d logins, want 1", logins)
+	}
+
+	server.SendProduct(tornadoWarning("1003.1"))
+	waitFor(t, "product after rejoin", func() bool {
+		return isArchived(client, "1003.1")
+	})
+}
+
+func TestXMPPStrayMUCElementReconnects(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+	server.WaitForJoin()
+
+	// The XMPP library can't parse a top level MUC element and drops the
+	// stream, so the supervisor has to reconnect
+	server.SendRaw("<x xmlns='http://jabber.org/protocol/muc'/>")
+	server.WaitForJoin()
+
+	if logins := server.Logins(); logins != 2 {
+		t.Errorf("server saw %d logins, want 2", logins)
+	}
+}
+
+func TestXMPPVersionQuery(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+	server.WaitForJoin()
+
+	server.QueryVersion()
+	server.WaitForVersionReply()
+}
+
+func TestXMPPStopLeavesMUC(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	client, errs := startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+	occupant := server.WaitForJoin()
+
+	client.sites[0].Stop()
+
+	if left := server.WaitForLeave(); left != occupant {
+		t.Errorf("left as %q, joined as %q", left, occupant)
+	}
+	if err := waitForExit(t, errs); err != nil {
+		t.Errorf("connection exited with %v", err)
+	}
+	if state, _ := client.sites[0].State(); state != ConnStateStopped {
+		t.Errorf("state is %s, want %s", state, ConnStateStopped)
+	}
+}
+
+func TestXMPPSequenceGapDetected(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeXMPPServer(t, fakePassword)
+	client, _ := startIntegrationClient(t, Config{NWWSIOServers: []string{server.Addr()}})
+	server.WaitForJoin()
+
+	// Stanzas are handled concurrently, so wait for each product before
+	// sending the next to keep them in sequence
+	for _, id := range []string{"2001.1", "2001.2", "2001.5"} {
+		product := tornadoWarning(id)
+		product.AwipsID = "KPAOUN"
+		product.Ttaaii = "NOUS44"
+		product.Text = "keep-alive " + id
+		server.SendProduct(product)
+		waitFor(t, "product "+id, func() bool {
+			return isArchived(client, id)
+		})
+	}
+
+	waitFor(t, "sequence gap", func() bool {
+		return client.gaps.Stats().Gaps == 1
+	})
+	gaps := client.gaps.RecentGaps(1)
+	if len(gaps) != 1 || gaps[0].First != 3 || gaps[0].Last != 4 {
+		t.Errorf("unexpected gaps %v", gaps)
+	}
+}
+
+func TestXMPPDualSiteDeduplicates(t *testing.T) {
+	t.Parallel()
+
+	first := newFakeXMPPServer(t, fakePassword)
+	second := newFakeXMPPServer(t, fakePassword)
+	client, _ := startIntegrationClient(t, Config{
+		NWWSIOServers: []string{first.Addr(), second.Addr()},
+		DualSite:      true,
+	})
+	first.WaitForJoin()
+	second.WaitForJoin()
+
+	duplicates := metricDuplicateProducts.Value()
+	first.SendProduct(tornadoWarning("3001.1"))
+	second.SendProduct(tornadoWarning("3001.1"))
+
+	waitFor(t, "duplicate dropped", func() bool {
+		return metricDuplicateProducts.Value() == duplicates+1
+	})
+	if count := client.archive.Count(); count != 1 {
+		t.Errorf("archive holds %d products, want 1", count)
+	}
+}
@@ -0,0 +1,449 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	fakeMUCRoom      = "nwws@conference.nwws-oi.weather.gov"
+	fakeWaitTimeout  = 10 * time.Second
+	fakeStreamHeader = "<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' id='%s' from='" + NWWSDomain + "' version='1.0'>"
+)
+
+// fakeProduct is a product the fake server broadcasts to the MUC
+type fakeProduct struct {
+	ID      string // processID.sequenceID
+	Cccc    string
+	Ttaaii  string
+	Issue   string
+	AwipsID string
+	Text    string
+}
+
+// fakeXMPPServer is an in-process stand-in for an NWWS-OI server. It accepts
+// SASL PLAIN logins, binds a resource, hosts the nwws MUC and broadcasts
+// scripted products to everyone who joined it. Tests can also make it reject
+// MUC joins, send stray stanzas and drop connections.
+type fakeXMPPServer struct {
+	t        *testing.T
+	listener net.Listener
+	password string
+
+	mu          sync.Mutex
+	sessions    map[*fakeXMPPSession]bool
+	logins      int
+	rejectJoins int // MUC joins still to be answered with an error presence
+	nextID      int
+
+	joins         chan string // occupant JIDs as they join the MUC
+	leaves        chan string // occupant JIDs as they leave the MUC
+	versionReplys chan string // IDs of answered version queries
+}
+
+// fakeXMPPSession is one client connection to the fake server
+type fakeXMPPSession struct {
+	conn    net.Conn
+	dec     *xml.Decoder
+	writeMu sync.Mutex
+
+	jid      string // full JID once bound
+	occupant string // occupant JID while joined to the MUC
+}
+
+// newFakeXMPPServer starts a fake server on a loopback port which accepts any
+// user with the given password. It is closed when the test finishes.
+func newFakeXMPPServer(t *testing.T, password string) *fakeXMPPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &fakeXMPPServer{
+		t:             t,
+		listener:      listener,
+		password:      password,
+		sessions:      make(map[*fakeXMPPSession]bool),
+		joins:         make(chan string, 16),
+		leaves:        make(chan string, 16),
+		versionReplys: make(chan string, 16),
+	}
+	go s.accept()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr returns the host:port the server is listening on
+func (s *fakeXMPPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops listening and drops every connection
+func (s *fakeXMPPServer) Close() {
+	_ = s.listener.Close()
+	s.DropConnections()
+}
+
+// Logins returns the number of successful SASL logins
+func (s *fakeXMPPServer) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.logins
+}
+
+// RejectJoins answers the next n MUC joins with an error presence
+func (s *fakeXMPPServer) RejectJoins(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rejectJoins = n
+}
+
+// DropConnections closes every connection without ending the stream, as a
+// network failure would
+func (s *fakeXMPPServer) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for session := range s.sessions {
+		_ = session.conn.Close()
+	}
+}
+
+// SendProduct broadcasts a product to every MUC occupant, returning how many
+// received it
+func (s *fakeXMPPServer) SendProduct(p fakeProduct) int {
+	var b strings.Builder
+	b.WriteString("<x xmlns='nwws-oi'")
+	for _, attr := range [][2]string{
+		{"cccc", p.Cccc},
+		{"ttaaii", p.Ttaaii},
+		{"issue", p.Issue},
+		{"awipsid", p.AwipsID},
+		{"id", p.ID},
+	} {
+		fmt.Fprintf(&b, " %s='%s'", attr[0], escapeXML(attr[1]))
+	}
+	fmt.Fprintf(&b, ">%s</x>", escapeXML(p.Text))
+	ext := b.String()
+
+	sent := 0
+	for _, session := range s.occupants() {
+		session.send("<message type='groupchat' from='%s/nwws-oi' to='%s'><body>%s issues %s</body>%s</message>",
+			fakeMUCRoom, session.jid, escapeXML(p.Cccc), escapeXML(p.AwipsID), ext)
+		sent++
+	}
+	return sent
+}
+
+// SendRaw writes raw XML to every MUC occupant
+func (s *fakeXMPPServer) SendRaw(raw string) {
+	for _, session := range s.occupants() {
+		session.send("%s", raw)
+	}
+}
+
+// QueryVersion sends a jabber:iq:version query to every connected session,
+// the replies are delivered on versionReplys
+func (s *fakeXMPPServer) QueryVersion() {
+	for _, session := range s.connected() {
+		s.mu.Lock()
+		s.nextID++
+		id := fmt.Sprintf("version-%d", s.nextID)
+		s.mu.Unlock()
+
+		session.send("<iq type='get' id='%s' from='%s' to='%s'><query xmlns='jabber:iq:version'/></iq>", id, NWWSDomain, session.jid)
+	}
+}
+
+// WaitForJoin waits for a client to join the MUC, returning its occupant JID
+func (s *fakeXMPPServer) WaitForJoin() string {
+	s.t.Helper()
+	return s.wait(s.joins, "MUC join")
+}
+
+// WaitForLeave waits for a client to leave the MUC, returning its occupant JID
+func (s *fakeXMPPServer) WaitForLeave() string {
+	s.t.Helper()
+	return s.wait(s.leaves, "MUC leave")
+}
+
+// WaitForVersionReply waits for a client to answer a version query
+func (s *fakeXMPPServer) WaitForVersionReply() string {
+	s.t.Helper()
+	return s.wait(s.versionReplys, "version reply")
+}
+
+func (s *fakeXMPPServer) wait(ch <-chan string, what string) string {
+	s.t.Helper()
+
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(fakeWaitTimeout):
+		s.t.Fatalf("timed out waiting for %s", what)
+		return ""
+	}
+}
+
+// occupants returns the sessions currently joined to the MUC
+func (s *fakeXMPPServer) occupants() []*fakeXMPPSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*fakeXMPPSession
+	for session := range s.sessions {
+		if session.occupant != "" {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+// connected returns the sessions which completed login and bind
+func (s *fakeXMPPServer) connected() []*fakeXMPPSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*fakeXMPPSession
+	for session := range s.sessions {
+		if session.jid != "" {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+func (s *fakeXMPPServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		session := &fakeXMPPSession{conn: conn, dec: xml.NewDecoder(conn)}
+		s.mu.Lock()
+		s.sessions[session] = true
+		s.mu.Unlock()
+
+		go func() {
+			defer func() {
+				_ = conn.Close()
+				s.mu.Lock()
+				delete(s.sessions, session)
+				s.mu.Unlock()
+			}()
+			if err := s.serve(session); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.t.Logf("fake XMPP session ended: %v", err)
+			}
+		}()
+	}
+}
+
+// serve runs a client session: stream negotiation, SASL, resource binding
+// and then stanzas until the stream or connection closes
+func (s *fakeXMPPServer) serve(session *fakeXMPPSession) error {
+	if err := session.openStream(); err != nil {
+		return err
+	}
+	session.send("<stream:features><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>PLAIN</mechanism></mechanisms></stream:features>")
+
+	user, err := s.authenticate(session)
+	if err != nil {
+		return err
+	}
+
+	// The client restarts the stream after authenticating
+	if err := session.openStream(); err != nil {
+		return err
+	}
+	session.send("<stream:features><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'/></stream:features>")
+
+	for {
+		token, err := session.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			if t.Name.Local == "stream" {
+				session.send("</stream:stream>")
+				return nil
+			}
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "iq":
+				err = s.handleIQ(session, user, t)
+			case "presence":
+				err = s.handlePresence(session, t)
+			default:
+				err = session.dec.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// openStream reads the client's stream header and answers with our own
+func (session *fakeXMPPSession) openStream() error {
+	for {
+		token, err := session.dec.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "stream" {
+				return fmt.Errorf("expected stream header, got <%s>", start.Name.Local)
+			}
+			session.send(fakeStreamHeader, fmt.Sprintf("%p", session))
+			return nil
+		}
+	}
+}
+
+// authenticate handles the SASL PLAIN exchange, returning the username
+func (s *fakeXMPPServer) authenticate(session *fakeXMPPSession) (string, error) {
+	var auth struct {
+		Mechanism string `xml:"mechanism,attr"`
+		Value     string `xml:",chardata"`
+	}
+	if err := nextElement(session.dec, "auth", &auth); err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(auth.Value)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if auth.Mechanism != "PLAIN" || len(parts) != 3 || parts[2] != s.password {
+		session.send("<failure xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><not-authorized/></failure></stream:stream>")
+		return "", errors.New("authentication rejected")
+	}
+
+	s.mu.Lock()
+	s.logins++
+	s.mu.Unlock()
+	session.send("<success xmlns='urn:ietf:params:xml:ns:xmpp-sasl'/>")
+	return parts[1], nil
+}
+
+// handleIQ answers resource binding and records replies to version queries
+func (s *fakeXMPPServer) handleIQ(session *fakeXMPPSession, user string, start xml.StartElement) error {
+	var iq struct {
+		Type string `xml:"type,attr"`
+		ID   string `xml:"id,attr"`
+		Bind *struct {
+			Resource string `xml:"resource"`
+		} `xml:"urn:ietf:params:xml:ns:xmpp-bind bind"`
+		Version *struct {
+			Name string `xml:"name"`
+		} `xml:"jabber:iq:version query"`
+	}
+	if err := session.dec.DecodeElement(&iq, &start); err != nil {
+		return err
+	}
+
+	switch {
+	case iq.Type == "set" && iq.Bind != nil:
+		jid := fmt.Sprintf("%s@%s/%s", user, NWWSDomain, iq.Bind.Resource)
+		s.mu.Lock()
+		session.jid = jid
+		s.mu.Unlock()
+		session.send("<iq type='result' id='%s'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><jid>%s</jid></bind></iq>", iq.ID, escapeXML(jid))
+	case iq.Type == "result" && iq.Version != nil:
+		s.versionReplys <- iq.ID
+	case iq.Type == "get" || iq.Type == "set":
+		session.send("<iq type='error' id='%s'><error type='cancel'><service-unavailable xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></iq>", iq.ID)
+	}
+	return nil
+}
+
+// handlePresence joins and leaves the MUC
+func (s *fakeXMPPServer) handlePresence(session *fakeXMPPSession, start xml.StartElement) error {
+	var presence struct {
+		To   string    `xml:"to,attr"`
+		Type string    `xml:"type,attr"`
+		MUC  *struct{} `xml:"http://jabber.org/protocol/muc x"`
+	}
+	if err := session.dec.DecodeElement(&presence, &start); err != nil {
+		return err
+	}
+
+	room, _, _ := strings.Cut(presence.To, "/")
+	if room != fakeMUCRoom {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if presence.Type == "unavailable" {
+		if session.occupant != "" {
+			session.occupant = ""
+			session.send("<presence type='unavailable' from='%s' to='%s'/>", escapeXML(presence.To), escapeXML(session.jid))
+			s.leaves <- presence.To
+		}
+		return nil
+	}
+	if presence.MUC == nil {
+		return nil
+	}
+
+	if s.rejectJoins > 0 {
+		s.rejectJoins--
+		session.send("<presence type='error' from='%s' to='%s'><error type='cancel'><conflict xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></presence>",
+			escapeXML(presence.To), escapeXML(session.jid))
+		return nil
+	}
+
+	session.occupant = presence.To
+	session.send("<presence from='%s' to='%s'><x xmlns='http://jabber.org/protocol/muc#user'><item affiliation='none' role='visitor'/><status code='110'/></x></presence>",
+		escapeXML(presence.To), escapeXML(session.jid))
+	s.joins <- presence.To
+	return nil
+}
+
+// send writes formatted XML to the session, ignoring errors from closed connections
+func (session *fakeXMPPSession) send(format string, args ...any) {
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+
+	_, _ = fmt.Fprintf(session.conn, format, args...)
+}
+
+// nextElement decodes the next element, which must have the given local name
+func nextElement(dec *xml.Decoder, name string, v any) error {
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != name {
+				return fmt.Errorf("expected <%s>, got <%s>", name, start.Name.Local)
+			}
+			return dec.DecodeElement(v, &start)
+		}
+	}
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
@@ -37,25 +39,22 @@ func main() {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
 
-	coreURL := os.Getenv("SEABIRD_HOST")
-	coreToken := os.Getenv("SEABIRD_TOKEN")
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatal().Err(err).Msg("Replay failed")
+		}
+		return
+	}
 
-	if coreURL == "" || coreToken == "" {
+	config := configFromEnv()
+	if config.SeabirdCoreURL == "" || config.SeabirdCoreToken == "" {
 		log.Fatal().Msg("Missing SEABIRD_HOST or SEABIRD_TOKEN")
 	}
-
-	nwwsioUsername := os.Getenv("NWWSIO_USERNAME")
-	nwwsioPassword := os.Getenv("NWWSIO_PASSWORD")
-	if nwwsioUsername == "" || nwwsioPassword == "" {
+	if config.NWWSIOUsername == "" || config.NWWSIOPassword == "" {
 		log.Fatal().Msg("Missing NWWSIO_USERNAME or NWWSIO_PASSWORD")
 	}
 
-	subscriptionFile := os.Getenv("SUBSCRIPTION_FILE")
-	if subscriptionFile == "" {
-		subscriptionFile = "./data/subscriptions.json"
-	}
-
-	c, err := client.NewSeabirdClient(coreURL, coreToken, nwwsioUsername, nwwsioPassword, subscriptionFile)
+	c, err := client.NewSeabirdClient(config)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize seabird client")
 	}
@@ -76,3 +75,83 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to run client")
 	}
 }
+
+// configFromEnv builds the client configuration from environment variables
+func configFromEnv() client.Config {
+	subscriptionFile := os.Getenv("SUBSCRIPTION_FILE")
+	if subscriptionFile == "" {
+		subscriptionFile = "./data/subscriptions.json"
+	}
+
+	eventFile := os.Getenv("EVENT_FILE")
+	if eventFile == "" {
+		eventFile = "./data/events.json"
+	}
+
+	archiveDir := os.Getenv("ARCHIVE_DIR")
+	if archiveDir == "" {
+		archiveDir = "./data/archive"
+	}
+
+	archiveRetention := client.DefaultArchiveRetention
+	if days := os.Getenv("ARCHIVE_RETENTION_DAYS"); days != "" {
+		parsed, err := strconv.Atoi(days)
+		if err != nil || parsed <= 0 {
+			log.Fatal().Str("value", days).Msg("Invalid ARCHIVE_RETENTION_DAYS")
+		}
+		archiveRetention = time.Duration(parsed) * 24 * time.Hour
+	}
+
+	// Comma-separated host:port NWWS-OI servers, in order of preference
+	var nwwsioServers []string
+	for _, server := range strings.Split(os.Getenv("NWWSIO_SERVERS"), ",") {
+		if trimmed := strings.TrimSpace(server); trimmed != "" {
+			nwwsioServers = append(nwwsioServers, trimmed)
+		}
+	}
+
+	stallThreshold := client.DefaultStallThreshold
+	if value := os.Getenv("STALL_THRESHOLD"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Fatal().Str("value", value).Msg("Invalid STALL_THRESHOLD")
+		}
+		stallThreshold = parsed
+	}
+
+	dualSite := false
+	if value := os.Getenv("NWWSIO_DUAL_SITE"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatal().Str("value", value).Msg("Invalid NWWSIO_DUAL_SITE")
+		}
+		dualSite = parsed
+	}
+
+	// Comma-separated user IDs allowed to run admin commands
+	var adminUsers []string
+	for _, admin := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if trimmed := strings.TrimSpace(admin); trimmed != "" {
+			adminUsers = append(adminUsers, trimmed)
+		}
+	}
+
+	return client.Config{
+		SeabirdCoreURL:   os.Getenv("SEABIRD_HOST"),
+		SeabirdCoreToken: os.Getenv("SEABIRD_TOKEN"),
+		NWWSIOUsername:   os.Getenv("NWWSIO_USERNAME"),
+		NWWSIOPassword:   os.Getenv("NWWSIO_PASSWORD"),
+		SubscriptionFile: subscriptionFile,
+		EventFile:        eventFile,
+		AdminUsers:       adminUsers,
+		ArchiveDir:       archiveDir,
+		ArchiveRetention: archiveRetention,
+		MetricsAddr:      os.Getenv("METRICS_ADDR"),
+		DualSite:         dualSite,
+		NWWSIOServers:    nwwsioServers,
+		StallThreshold:   stallThreshold,
+		AdminChannel:     os.Getenv("ADMIN_CHANNEL"),
+		CaptureFile:      os.Getenv("CAPTURE_FILE"),
+	}
+
+}
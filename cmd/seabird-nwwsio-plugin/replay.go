@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seabird-chat/seabird-nwwsio-plugin/client"
+)
+
+// runReplay feeds a capture file through the message pipeline without
+// connecting to NWWS-OI. Subscriptions, events and the archive are kept in a
+// temporary directory unless -state-dir is given, so replays never touch the
+// live state. Alerts are only logged unless -deliver is given, in which case
+// they are sent through seabird-core.
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := flags.Float64("speed", 0, "Replay speed relative to the original pacing, 0 for as fast as possible")
+	stateDir := flags.String("state-dir", "", "Directory for subscriptions, events and the archive, a temporary directory if empty")
+	subscriptionFile := flags.String("subscriptions", "", "Subscriptions file to copy into the state directory before replaying")
+	deliver := flags.Bool("deliver", false, "Send alerts through seabird-core (SEABIRD_HOST) instead of logging them")
+	flags.Usage = func() {
+		log.Info().Msg("Usage: seabird-nwwsio-plugin replay [-speed N] [-state-dir DIR] [-subscriptions FILE] [-deliver] <capture file>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *speed < 0 {
+		return fmt.Errorf("replay speed cannot be negative: %v", *speed)
+	}
+
+	dir := *stateDir
+	if dir == "" {
+		var err error
+		dir, err = os.MkdirTemp("", "nwwsio-replay-")
+		if err != nil {
+			return fmt.Errorf("failed to create replay state directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+	}
+	log.Info().Str("dir", dir).Msg("Keeping replay state in directory")
+
+	if *subscriptionFile != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create replay state directory: %w", err)
+		}
+		if err := client.CopyFile(*subscriptionFile, filepath.Join(dir, "subscriptions.json")); err != nil {
+			return fmt.Errorf("failed to copy subscriptions file: %w", err)
+		}
+	}
+
+	env := configFromEnv()
+	config := client.Config{
+		SubscriptionFile: filepath.Join(dir, "subscriptions.json"),
+		EventFile:        filepath.Join(dir, "events.json"),
+		ArchiveDir:       filepath.Join(dir, "archive"),
+		ArchiveRetention: env.ArchiveRetention,
+		AdminUsers:       env.AdminUsers,
+		DualSite:         env.DualSite,
+	}
+	if *deliver {
+		if env.SeabirdCoreURL == "" {
+			return errors.New("-deliver requires SEABIRD_HOST")
+		}
+		config.SeabirdCoreURL = env.SeabirdCoreURL
+		config.SeabirdCoreToken = env.SeabirdCoreToken
+		config.AdminChannel = env.AdminChannel
+	} else {
+		log.Info().Msg("Alerts will be logged instead of delivered, use -deliver to send them")
+	}
+
+	c, err := client.NewSeabirdClient(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize seabird client: %w", err)
+	}
+	defer func() {
+		if err := c.Shutdown(); err != nil {
+			log.Error().Err(err).Msg("Error during shutdown")
+		}
+	}()
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open capture file: %w", err)
+	}
+	defer f.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	start := time.Now()
+	count, err := c.Replay(ctx, f, *speed)
+	if err != nil {
+		return fmt.Errorf("replay stopped after %d products: %w", count, err)
+	}
+	log.Info().Int("products", count).Dur("elapsed", time.Since(start)).Msg("Replay finished")
+	return nil
+}
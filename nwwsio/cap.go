@@ -128,22 +128,72 @@ func (a *Area) GetGeocode(name string) string {
 
 // GetAllUGCCodes returns all UGC (Universal Geographic Code) values from the area
 func (a *Area) GetAllUGCCodes() []string {
+	var codes []string
 	for _, code := range a.Geocode {
 		if code.ValueName == "UGC" {
-			// UGC codes are space-separated
-			return strings.Fields(code.Value)
+			// NWS CAP repeats the geocode per UGC, but values may also be space-separated
+			codes = append(codes, strings.Fields(code.Value)...)
 		}
 	}
-	return nil
+	return codes
 }
 
 // GetAllSAMECodes returns all SAME (Specific Area Message Encoding) codes from the area
 func (a *Area) GetAllSAMECodes() []string {
+	var codes []string
 	for _, code := range a.Geocode {
 		if code.ValueName == "SAME" {
-			// SAME codes are space-separated
-			return strings.Fields(code.Value)
+			// NWS CAP repeats the geocode per SAME code, but values may also be space-separated
+			codes = append(codes, strings.Fields(code.Value)...)
 		}
 	}
-	return nil
+	return codes
+}
+
+// CAP severity, urgency and certainty each rank their values from 1 for the
+// least to 4 for the most, with Unknown and unrecognized values ranked 0:
+//
+//	Severity:  Minor < Moderate < Severe < Extreme
+//	Urgency:   Past < Future < Expected < Immediate
+//	Certainty: Unlikely < Possible < Likely < Observed
+//
+// CAP 1.0's "Very Likely" certainty ranks with Likely.
+var (
+	severityRanks = map[string]int{
+		"minor":    1,
+		"moderate": 2,
+		"severe":   3,
+		"extreme":  4,
+	}
+	urgencyRanks = map[string]int{
+		"past":      1,
+		"future":    2,
+		"expected":  3,
+		"immediate": 4,
+	}
+	certaintyRanks = map[string]int{
+		"unlikely":    1,
+		"possible":    2,
+		"likely":      3,
+		"very likely": 3,
+		"observed":    4,
+	}
+)
+
+// SeverityRank orders severities from 1 for Minor to 4 for Extreme, or 0 when
+// unknown
+func SeverityRank(severity string) int {
+	return severityRanks[strings.ToLower(strings.TrimSpace(severity))]
+}
+
+// UrgencyRank orders urgencies from 1 for Past to 4 for Immediate, or 0 when
+// unknown
+func UrgencyRank(urgency string) int {
+	return urgencyRanks[strings.ToLower(strings.TrimSpace(urgency))]
+}
+
+// CertaintyRank orders certainties from 1 for Unlikely to 4 for Observed, or 0
+// when unknown
+func CertaintyRank(certainty string) int {
+	return certaintyRanks[strings.ToLower(strings.TrimSpace(certainty))]
 }
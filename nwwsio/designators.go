@@ -0,0 +1,275 @@
+package nwwsio
+
+import "strings"
+
+/*
+Documentation:
+* https://www.weather.gov/tg/table
+* WMO Manual on the GTS (WMO-No. 386), Attachment II-5
+
+DataTable names the tables which decode T2, A1 and A2 for each T1. Only
+Table B1 (T2 for text T1 values) and part of Table C1 (A1A2 country or area)
+are decoded here, since those cover the text products NWWS-OI carries:
+
+- Tables B2-B7 (T2 for grids, BUFR, oceanographic GRIB, satellite imagery,
+  pictorial and XML products) are not implemented.
+- Tables C2-C7 (ship and buoy areas, grid areas, reference times, BUFR and
+  CREX data types) are not implemented.
+- Table C1 only lists North America, the Caribbean, the Pacific and the
+  WMO geographical areas. Other countries are not listed.
+
+GetSubtype and GetArea return an empty string for anything outside these.
+*/
+
+// TableB1 maps T1 then T2 to the data type designator description for the
+// T1 values which use Table B1
+var TableB1 = map[string]map[string]string{
+	"A": {
+		"C": "Cyclone",
+		"G": "Hydrological/marine",
+		"H": "Thickness",
+		"I": "Ice",
+		"O": "Ozone layer",
+		"R": "Radar",
+		"S": "Surface",
+		"U": "Upper air",
+		"W": "Weather summary",
+		"X": "Miscellaneous",
+	},
+	"C": {
+		"A": "Climatic anomalies",
+		"E": "Monthly means (upper air)",
+		"H": "Monthly means (surface)",
+		"O": "Monthly means (ocean areas)",
+		"S": "Monthly means (surface)",
+		"U": "Monthly means (upper air)",
+	},
+	"F": {
+		"A": "Aviation area/GAMET/advisories",
+		"B": "Upper winds and temperatures",
+		"C": "Aerodrome (VT < 12 hours)",
+		"D": "Radiological trajectory dose",
+		"E": "Extended",
+		"F": "Shipping",
+		"G": "Hydrological",
+		"H": "Upper-air thickness",
+		"I": "Iceberg",
+		"J": "Radio warning service",
+		"K": "Tropical cyclone advisories",
+		"L": "Local/area",
+		"M": "Temperature extremes",
+		"O": "Guidance",
+		"P": "Public",
+		"Q": "Other shipping",
+		"R": "Aviation route",
+		"S": "Surface",
+		"T": "Aerodrome (VT >= 12 hours)",
+		"U": "Upper air",
+		"V": "Volcanic ash advisories",
+		"W": "Winter sports",
+		"X": "Miscellaneous",
+		"Z": "Shipping area",
+	},
+	"N": {
+		"G": "Hydrological",
+		"H": "Marine",
+		"N": "Nuclear emergency response",
+		"O": "METNO/WIFMA",
+		"P": "Product generation delay",
+		"T": "TEST MSG [System related]",
+		"W": "Warning related and/or cancellation",
+	},
+	"S": {
+		"A": "Aviation routine reports",
+		"B": "Radar reports (part A)",
+		"C": "Radar reports (part B)",
+		"D": "Radar reports (parts A & B)",
+		"E": "Seismic data",
+		"F": "Atmospherics reports",
+		"G": "Radiological data report",
+		"I": "Intermediate synoptic hour",
+		"M": "Main synoptic hour",
+		"N": "Non-standard synoptic hour",
+		"O": "Oceanographic data",
+		"P": "Special aviation weather reports",
+		"R": "Hydrological (river) reports",
+		"S": "Drifting buoy reports",
+		"T": "Sea ice",
+		"U": "Snow depth",
+		"V": "Lake ice",
+		"W": "Wave information",
+		"X": "Miscellaneous",
+		"Y": "Seismic waveform data",
+		"Z": "Sea-level data and deep-ocean tsunami data",
+	},
+	"T": {
+		"B": "Satellite orbit parameters",
+		"C": "Satellite cloud interpretations",
+		"H": "Satellite remote upper-air soundings",
+		"R": "Clear radiance observations",
+		"T": "Sea surface temperatures",
+		"W": "Winds and cloud temperatures",
+		"X": "Miscellaneous",
+	},
+	"U": {
+		"A": "Aircraft reports",
+		"D": "Aircraft reports",
+		"E": "Upper-level pressure, temperature, humidity and wind (part D)",
+		"F": "Upper-level pressure, temperature, humidity and wind (parts C and D)",
+		"G": "Upper wind (part B)",
+		"H": "Upper wind (part C)",
+		"I": "Upper wind (parts A and B)",
+		"K": "Upper-level pressure, temperature, humidity and wind (part B)",
+		"L": "Upper-level pressure, temperature, humidity and wind (part C)",
+		"M": "Upper-level pressure, temperature, humidity and wind (parts A and B)",
+		"N": "Rocketsonde reports",
+		"P": "Upper wind (part A)",
+		"Q": "Upper wind (part D)",
+		"R": "Aircraft reports",
+		"S": "Upper-level pressure, temperature, humidity and wind (part A)",
+		"T": "Aircraft reports",
+		"U": "Upper-level pressure, temperature, humidity and wind",
+		"X": "Miscellaneous",
+		"Y": "Upper wind (parts C and D)",
+		"Z": "Upper-level pressure, temperature, humidity and wind from sondes released by carrier balloons or aircraft",
+	},
+	"W": {
+		"A": "AIRMET",
+		"C": "Tropical cyclone (SIGMET)",
+		"E": "Tsunami",
+		"F": "Tornado",
+		"G": "Hydrological/river flood",
+		"H": "Marine/coastal flood",
+		"O": "Other",
+		"R": "Humanitarian activities",
+		"S": "SIGMET",
+		"T": "Tropical cyclone (typhoon/hurricane)",
+		"U": "Severe thunderstorm",
+		"V": "Volcanic ash clouds (SIGMET)",
+		"W": "Warnings and weather summary",
+		"X": "Miscellaneous",
+	},
+}
+
+// TableC1 maps A1A2 to the country, territory or area it designates. It is
+// partial, see above.
+var TableC1 = map[string]string{
+	// North America and the Caribbean
+	"AK": "Alaska",
+	"BA": "Bahamas",
+	"BE": "Bermuda",
+	"BH": "Belize",
+	"BR": "Barbados",
+	"CN": "Canada",
+	"CO": "Colombia",
+	"CS": "Costa Rica",
+	"CU": "Cuba",
+	"DR": "Dominican Republic",
+	"GU": "Guatemala",
+	"HA": "Haiti",
+	"HO": "Honduras",
+	"HW": "Hawaii",
+	"JM": "Jamaica",
+	"MX": "Mexico",
+	"NK": "Nicaragua",
+	"PM": "Panama",
+	"PR": "Puerto Rico",
+	"TD": "Trinidad and Tobago",
+	"US": "United States",
+
+	// Pacific
+	"AU": "Australia",
+	"FJ": "Fiji",
+	"GM": "Guam",
+	"JP": "Japan",
+	"KR": "Republic of Korea",
+	"NZ": "New Zealand",
+	"PH": "Philippines",
+
+	// Geographical areas
+	"AA": "Antarctic",
+	"AC": "Arctic",
+	"AE": "South-East Asia",
+	"AF": "Africa",
+	"AO": "West Africa",
+	"AP": "Southern Africa",
+	"AS": "Asia",
+	"AW": "Near East",
+	"BQ": "Baltic Sea area",
+	"CA": "Caribbean and Central America",
+	"EA": "East Africa",
+	"EC": "East China Sea area",
+	"EE": "Eastern Europe",
+	"EM": "Middle Europe",
+	"EN": "Northern Europe",
+	"EU": "Europe",
+	"EW": "Western Europe",
+	"FE": "Far East",
+	"GA": "Gulf of Alaska area",
+	"GX": "Gulf of Mexico area",
+	"IO": "Indian Ocean area",
+	"ME": "Eastern Mediterranean area",
+	"MM": "Mediterranean area",
+	"MW": "Western Mediterranean area",
+	"NA": "North America",
+	"NT": "North Atlantic area",
+	"OC": "Oceania",
+	"PA": "Pacific area",
+	"PE": "Persian Gulf area",
+	"PN": "North Pacific area",
+	"PQ": "Western North Pacific",
+	"PS": "South Pacific area",
+	"PW": "Western Pacific area",
+	"PZ": "Eastern Pacific area",
+	"SA": "South America",
+	"SE": "Southern Ocean area",
+	"ST": "South Atlantic area",
+	"XE": "Eastern hemisphere",
+	"XN": "Northern hemisphere",
+	"XS": "Southern hemisphere",
+	"XT": "Tropical belt",
+	"XW": "Western hemisphere",
+}
+
+// getDataEntry returns the DataTable entry for T1
+func (w *WMOProductID) getDataEntry() (DataEntry, bool) {
+	for _, entry := range DataTable {
+		if entry.T1 == w.T1 {
+			return entry, true
+		}
+	}
+	return DataEntry{}, false
+}
+
+// GetSubtype returns the T2 description (e.g., "Severe thunderstorm" for WU),
+// or an empty string when T1 doesn't use Table B1 or T2 isn't in it
+func (w *WMOProductID) GetSubtype() string {
+	entry, found := w.getDataEntry()
+	if !found || entry.T2 != "B1" {
+		return ""
+	}
+	return TableB1[w.T1][w.T2]
+}
+
+// GetArea returns the A1A2 geographic area (e.g., "United States" for US),
+// or an empty string when T1 doesn't use Table C1 or A1A2 isn't listed
+func (w *WMOProductID) GetArea() string {
+	entry, found := w.getDataEntry()
+	if !found || !strings.Contains(entry.A1, "C1") {
+		return ""
+	}
+	return TableC1[w.A1+w.A2]
+}
+
+// Describe returns the data type with whichever of the T2 subtype and A1A2
+// area are known (e.g., "Warnings, Severe thunderstorm - United States")
+func (w *WMOProductID) Describe() string {
+	description := w.GetDataType()
+	if subtype := w.GetSubtype(); subtype != "" {
+		description += ", " + subtype
+	}
+	if area := w.GetArea(); area != "" {
+		description += " - " + area
+	}
+	return description
+}
@@ -0,0 +1,36 @@
+/*
+Package nwwsio decodes products from the NOAA Weather Wire Service Open
+Interface (NWWS-OI).
+
+It covers the nwws-oi XMPP stanza extension, WMO data designators, AWIPS
+product identifiers and the product catalog, CAP v1.2 alerts, VTEC strings,
+UGC and SAME area codes and warning polygons. It has no dependency on the
+seabird plugin which is built on it and can be imported on its own:
+
+	import "github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+
+Importing the package registers NWWSOIMessageXExtension with the
+gosrc.io/xmpp stanza registry, so groupchat messages from the nwws MUC carry
+it as an extension:
+
+	var ext nwwsio.NWWSOIMessageXExtension
+	if msg.Get(&ext) {
+		awips, err := ext.ParseAwipsID()
+		...
+	}
+
+# API stability
+
+The exported API of this package follows the module's semantic version.
+Within a major version exported identifiers are not removed or renamed,
+function signatures and struct field types do not change and parse results
+for input that already parsed do not change meaning. New identifiers and
+struct fields may be added in minor releases.
+
+The lookup tables (CommonProducts, DataTable, VTECPhenomena, VTECActions,
+VTECSignificance and VTECProductClasses) follow the NWS and WMO
+publications they are built from. Entries may be added or corrected in any
+release, so callers should handle codes which aren't in them. Error
+messages are not part of the API.
+*/
+package nwwsio
@@ -0,0 +1,65 @@
+package nwwsio
+
+import "strings"
+
+/*
+Documentation:
+* https://www.weather.gov/media/directives/010_pdfs/pd01005011curr.pdf (NWSI 10-511)
+* https://www.weather.gov/media/directives/010_pdfs/pd01009003curr.pdf (NWSI 10-922)
+
+Forecasters escalate the most dangerous events beyond the usual warning:
+
+PARTICULARLY DANGEROUS SITUATION - used in tornado and severe thunderstorm
+watches, and in warnings for a confirmed large tornado
+TORNADO EMERGENCY - a confirmed violent tornado threatening a populated area,
+tagged TORNADO DAMAGE THREAT...CATASTROPHIC
+FLASH FLOOD EMERGENCY - severe flooding threatening lives, tagged
+FLASH FLOOD DAMAGE THREAT...CATASTROPHIC
+
+The escalation appears in the headline or body text, in either case.
+*/
+
+// Escalations, in decreasing order of severity
+const (
+	EscalationTornadoEmergency    = "TORNADO EMERGENCY"
+	EscalationFlashFloodEmergency = "FLASH FLOOD EMERGENCY"
+	EscalationPDS                 = "PARTICULARLY DANGEROUS SITUATION"
+)
+
+var escalationPhrases = []string{
+	EscalationTornadoEmergency,
+	EscalationFlashFloodEmergency,
+	EscalationPDS,
+}
+
+// FindEscalations returns the escalations in a product, most severe first.
+// Escalations are found in the text and implied by catastrophic damage threat
+// tags. tags may be nil.
+func FindEscalations(text string, tags *ThreatTags) []string {
+	found := make(map[string]bool)
+
+	// Normalize whitespace so phrases wrapped over lines are still found
+	normalized := strings.Join(strings.Fields(strings.ToUpper(text)), " ")
+	for _, phrase := range escalationPhrases {
+		if strings.Contains(normalized, phrase) {
+			found[phrase] = true
+		}
+	}
+
+	if tags != nil {
+		if strings.EqualFold(tags.TornadoDamageThreat, DamageThreatCatastrophic) {
+			found[EscalationTornadoEmergency] = true
+		}
+		if strings.EqualFold(tags.FlashFloodDamageThreat, DamageThreatCatastrophic) {
+			found[EscalationFlashFloodEmergency] = true
+		}
+	}
+
+	var result []string
+	for _, phrase := range escalationPhrases {
+		if found[phrase] {
+			result = append(result, phrase)
+		}
+	}
+	return result
+}
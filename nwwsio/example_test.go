@@ -0,0 +1,117 @@
+package nwwsio_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func ExampleNWWSOIMessageXExtension() {
+	ext := nwwsio.NWWSOIMessageXExtension{
+		Cccc:    "KARX",
+		Ttaaii:  "SRUS83",
+		Issue:   "2013-05-25T02:20:34Z",
+		AwipsID: "RR8ARX",
+		ID:      "10313.6",
+	}
+
+	wmo, _ := ext.ParseTtaaii()
+	awips, _ := ext.ParseAwipsID()
+	process, sequence, _ := ext.GetSequenceID()
+
+	fmt.Println(wmo.GetDataType())
+	fmt.Println(awips.NNN, awips.XXX, awips.GetProductCategory())
+	fmt.Println(process, sequence)
+	// Output:
+	// Surface data
+	// RR8 ARX Hydrology
+	// 10313 6
+}
+
+func ExampleParseVTEC() {
+	vtec, err := nwwsio.ParseVTEC("/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(vtec)
+	fmt.Println(vtec.GetActionName(), "until", vtec.End.Format(time.Kitchen))
+	// Output:
+	// Tornado Warning #42 from KOUN
+	// New until 10:45PM
+}
+
+func ExampleFindUGC() {
+	text := "OKC027-087-109-\n062245-\n\n/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/\n"
+	issued := time.Date(2026, time.May, 6, 22, 14, 0, 0, time.UTC)
+
+	for _, group := range nwwsio.FindUGC(text, issued) {
+		fmt.Println(group.Codes, "expires", group.Expires.Format(time.RFC3339))
+	}
+	// Output:
+	// [OKC027 OKC087 OKC109] expires 2026-05-06T22:45:00Z
+}
+
+func ExampleFindWMOHeading() {
+	text := "\n\n111 \nSRUS83 KARX 250220 CCA\nRR8ARX\n\n: AUTOMATED GAUGE DATA\n"
+	issued := time.Date(2013, time.May, 25, 2, 20, 34, 0, time.UTC)
+
+	heading, err := nwwsio.FindWMOHeading(text)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(heading.Key(), heading.BBB, heading.IsCorrection())
+	fmt.Println(heading.Time(issued).Format(time.RFC3339))
+	// Output:
+	// SRUS83 KARX 250220 CCA true
+	// 2013-05-25T02:20:00Z
+}
+
+func ExampleWMOProductID_Describe() {
+	ext := nwwsio.NWWSOIMessageXExtension{Ttaaii: "WUUS53"}
+
+	wmo, _ := ext.ParseTtaaii()
+	fmt.Println(wmo.GetSubtype())
+	fmt.Println(wmo.GetArea())
+	fmt.Println(wmo.Describe())
+	// Output:
+	// Severe thunderstorm
+	// United States
+	// Warnings, Severe thunderstorm - United States
+}
+
+func ExampleSplitSegments() {
+	text := "WWUS43 KDTX 150900\nWSWDTX\n\nURGENT - WINTER WEATHER MESSAGE\n" +
+		"National Weather Service Detroit/Pontiac MI\n400 AM EST Mon Jan 15 2026\n\n" +
+		"MIZ047>049-151700-\n/O.EXT.KDTX.WW.Y.0003.000000T0000Z-260115T1700Z/\nMidland-Bay-Huron-\n\n" +
+		"...WINTER WEATHER ADVISORY REMAINS IN EFFECT UNTIL\nNOON EST TODAY...\n\n* WHAT...Snow.\n\n$$\n\n" +
+		"MIZ053>055-151700-\n/O.CAN.KDTX.WW.Y.0003.000000T0000Z-260115T1700Z/\nGenesee-Lapeer-St. Clair-\n\n" +
+		"...WINTER WEATHER ADVISORY IS CANCELLED...\n\n$$\n\nSMITH\n"
+	issued := time.Date(2026, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+	product := nwwsio.SplitSegments(text, issued)
+	for _, segment := range product.Segments {
+		fmt.Println(segment.Codes(), segment.VTEC[0].Action, segment.Headline)
+	}
+	fmt.Println(product.Signature)
+	// Output:
+	// [MIZ047 MIZ048 MIZ049] EXT WINTER WEATHER ADVISORY REMAINS IN EFFECT UNTIL NOON EST TODAY
+	// [MIZ053 MIZ054 MIZ055] CAN WINTER WEATHER ADVISORY IS CANCELLED
+	// SMITH
+}
+
+func ExampleFindThreatTags() {
+	text := "LAT...LON 4231 8312 4229 8290 4215 8301\n" +
+		"TIME...MOT...LOC 2102Z 245DEG 35KT 4231 8312\n\n" +
+		"TORNADO...RADAR INDICATED\nTORNADO DAMAGE THREAT...CONSIDERABLE\n" +
+		"MAX HAIL SIZE...1.75 IN\nMAX WIND GUST...70 MPH\n\n$$\n"
+
+	tags := nwwsio.FindThreatTags(text)
+	fmt.Println(tags.Tornado, tags.TornadoDamageThreat, tags.MaxHailSize, tags.MaxWindGust)
+	fmt.Println(tags.Motion, tags.Motion.Locations)
+	// Output:
+	// RADAR INDICATED CONSIDERABLE 1.75 70
+	// moving ENE at 40 mph [42.3100,-83.1200]
+}
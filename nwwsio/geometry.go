@@ -0,0 +1,189 @@
+package nwwsio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/media/directives/010_pdfs/pd01017001curr.pdf (NWSI 10-1701)
+
+Storm-based warnings carry their polygon in the product text as pairs of
+latitude and longitude in hundredths of a degree, with longitude given as
+degrees west and the trailing pair closing the polygon:
+
+LAT...LON 4231 8312 4229 8290 4215 8301
+      4218 8322
+
+CAP carries the same polygon as space-separated "lat,lon" pairs with the
+first and last pair equal:
+
+42.31,-83.12 42.29,-82.90 42.15,-83.01 42.18,-83.22 42.31,-83.12
+*/
+
+const latLonMarker = "LAT...LON"
+
+// Point is a location in decimal degrees
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// ParsePoint parses a "lat,lon" pair in decimal degrees
+func ParsePoint(value string) (Point, error) {
+	latStr, lonStr, found := strings.Cut(strings.TrimSpace(value), ",")
+	if !found {
+		return Point{}, fmt.Errorf("invalid point %q: expected lat,lon", value)
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid latitude %q: %w", latStr, err)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid longitude %q: %w", lonStr, err)
+	}
+
+	point := Point{Lat: lat, Lon: lon}
+	if err := point.Validate(); err != nil {
+		return Point{}, err
+	}
+	return point, nil
+}
+
+// Validate checks the point is within the valid range of latitude and longitude
+func (p Point) Validate() error {
+	if p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %.4f out of range -90 to 90", p.Lat)
+	}
+	if p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %.4f out of range -180 to 180", p.Lon)
+	}
+	return nil
+}
+
+// String returns the point as "lat,lon"
+func (p Point) String() string {
+	return fmt.Sprintf("%.4f,%.4f", p.Lat, p.Lon)
+}
+
+// Polygon is a closed ring of points
+type Polygon []Point
+
+// ParseCAPPolygon parses a CAP polygon of space-separated "lat,lon" pairs
+func ParseCAPPolygon(value string) (Polygon, error) {
+	var polygon Polygon
+	for _, pair := range strings.Fields(value) {
+		point, err := ParsePoint(pair)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CAP polygon: %w", err)
+		}
+		polygon = append(polygon, point)
+	}
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("invalid CAP polygon: expected at least 3 points, got %d", len(polygon))
+	}
+	return polygon, nil
+}
+
+// ParseLatLon parses the pairs following a LAT...LON marker. Values are in
+// hundredths of a degree with longitude given as degrees west.
+func ParseLatLon(value string) (Polygon, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(value), latLonMarker))
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("invalid LAT...LON: odd number of values (%d)", len(fields))
+	}
+
+	var polygon Polygon
+	for i := 0; i < len(fields); i += 2 {
+		lat, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid LAT...LON latitude %q: %w", fields[i], err)
+		}
+		lon, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid LAT...LON longitude %q: %w", fields[i+1], err)
+		}
+		polygon = append(polygon, Point{Lat: float64(lat) / 100, Lon: -float64(lon) / 100})
+	}
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("invalid LAT...LON: expected at least 3 points, got %d", len(polygon))
+	}
+
+	// Text polygons are implicitly closed
+	if polygon[0] != polygon[len(polygon)-1] {
+		polygon = append(polygon, polygon[0])
+	}
+	return polygon, nil
+}
+
+// FindLatLonPolygons returns every LAT...LON polygon found in a product's text.
+// The pairs may continue onto following indented lines.
+func FindLatLonPolygons(text string) []Polygon {
+	var result []Polygon
+	lines := strings.Split(text, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, latLonMarker) {
+			continue
+		}
+
+		values := []string{line}
+		for i+1 < len(lines) && isLatLonContinuation(lines[i+1]) {
+			i++
+			values = append(values, strings.TrimSpace(lines[i]))
+		}
+
+		polygon, err := ParseLatLon(strings.Join(values, " "))
+		if err != nil {
+			continue
+		}
+		result = append(result, polygon)
+	}
+
+	return result
+}
+
+// isLatLonContinuation reports whether a line holds only coordinate values
+func isLatLonContinuation(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, field := range fields {
+		if !isDigits(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains reports whether the point lies inside the polygon using ray casting
+func (p Polygon) Contains(point Point) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lon < (b.Lon-a.Lon)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// GetPolygons returns the decoded polygons from the area, skipping invalid ones
+func (a *Area) GetPolygons() []Polygon {
+	var result []Polygon
+	for _, raw := range a.Polygon {
+		polygon, err := ParseCAPPolygon(raw)
+		if err != nil {
+			continue
+		}
+		result = append(result, polygon)
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package nwwsio_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestParseLatLon(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string // polygon, empty when an error is expected
+	}{
+		{
+			name:  "unclosed ring is closed",
+			value: "LAT...LON 3500 9760 3540 9760 3540 9720",
+			want:  "[35.0000,-97.6000 35.4000,-97.6000 35.4000,-97.2000 35.0000,-97.6000]",
+		},
+		{
+			name:  "closed ring is kept",
+			value: "LAT...LON 3500 9760 3540 9760 3540 9720 3500 9760",
+			want:  "[35.0000,-97.6000 35.4000,-97.6000 35.4000,-97.2000 35.0000,-97.6000]",
+		},
+		{
+			name:  "five digit longitude",
+			value: "LAT...LON 6120 14990 6130 14990 6130 14970",
+			want:  "[61.2000,-149.9000 61.3000,-149.9000 61.3000,-149.7000 61.2000,-149.9000]",
+		},
+		{name: "empty", value: ""},
+		{name: "marker only", value: "LAT...LON"},
+		{name: "odd number of values", value: "LAT...LON 3500 9760 3540 9760 3540"},
+		{name: "single value", value: "LAT...LON 3500"},
+		{name: "two points", value: "LAT...LON 3500 9760 3540 9760"},
+		{name: "not a number", value: "LAT...LON 3500 9760 3540 97X0 3540 9720"},
+		{name: "decimal", value: "LAT...LON 35.00 97.60 35.40 97.60 35.40 97.20"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			polygon, err := nwwsio.ParseLatLon(test.value)
+			if test.want == "" {
+				if err == nil {
+					t.Errorf("got %v, want an error", polygon)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if got := fmt.Sprint(polygon); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindLatLonPolygons(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int // number of polygons
+	}{
+		{
+			name: "continuation lines",
+			text: "LAT...LON 3500 9760 3540 9760\n      3540 9720 3500 9720\n\nTIME...MOT...LOC 2214Z 245DEG 35KT 3520 9740\n",
+			want: 1,
+		},
+		{
+			name: "odd number of values across lines",
+			text: "LAT...LON 3500 9760 3540 9760\n      3540 9720 3500\n\n$$\n",
+		},
+		{
+			name: "truncated at the end of the product",
+			text: "LAT...LON 3500 9760 3540",
+		},
+		{
+			name: "bad polygon does not hide a later one",
+			text: "LAT...LON 3500 9760\n\n$$\n\nLAT...LON 3500 9760 3540 9760 3540 9720\n\n$$\n",
+			want: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := nwwsio.FindLatLonPolygons(test.text); len(got) != test.want {
+				t.Errorf("got %v, want %d polygons", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	polygon, err := nwwsio.ParseLatLon("LAT...LON 3500 9760 3540 9760 3540 9720 3500 9720")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		point nwwsio.Point
+		want  bool
+	}{
+		{nwwsio.Point{Lat: 35.2, Lon: -97.4}, true},
+		{nwwsio.Point{Lat: 35.5, Lon: -97.4}, false},
+		{nwwsio.Point{Lat: 35.2, Lon: -97.1}, false},
+		{nwwsio.Point{Lat: 35.2, Lon: 97.4}, false},
+	} {
+		if got := polygon.Contains(test.point); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.point, got, test.want)
+		}
+	}
+
+	// An empty polygon contains nothing
+	if (nwwsio.Polygon{}).Contains(nwwsio.Point{Lat: 35.2, Lon: -97.4}) {
+		t.Error("empty polygon contains a point")
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string // point, empty when an error is expected
+	}{
+		{value: "35.2,-97.4", want: "35.2000,-97.4000"},
+		{value: " 35.2 , -97.4 ", want: "35.2000,-97.4000"},
+		{value: ""},
+		{value: "35.2"},
+		{value: "35.2,"},
+		{value: "north,-97.4"},
+		{value: "91,-97.4"},
+		{value: "35.2,-181"},
+		{value: "35.2,-97.4,10"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			point, err := nwwsio.ParsePoint(test.value)
+			if test.want == "" {
+				if err == nil {
+					t.Errorf("got %s, want an error", point)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if point.String() != test.want {
+				t.Errorf("got %s, want %s", point, test.want)
+			}
+		})
+	}
+}
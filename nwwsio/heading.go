@@ -0,0 +1,175 @@
+package nwwsio
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/tg/head
+* https://www.weather.gov/tg/bbb
+
+WMO Abbreviated Heading Format:
+T1T2A1A2ii CCCC YYGGgg (BBB)
+
+T1T2A1A2ii - Six character data designator, the same as the ttaaii attribute
+CCCC       - Four character issuing center
+YYGGgg     - Day, hour and minute in UTC, often written DDHHMM
+BBB        - Optional indicator for products which aren't the original issuance
+
+BBB Indicators:
+RRx - Delayed or additional (retransmitted) product
+CCx - Correction to a previously issued product
+AAx - Amendment to a previously issued product
+Pxx - Segment of a product split into several messages
+
+x runs from A for the first RR, CC or AA of a product to X for the 24th.
+
+Example:
+SRUS83 KARX 250220 RRA
+*/
+
+var (
+	wmoHeadingPattern = regexp.MustCompile(`^([A-Z]{4}\d{2}) ([A-Z0-9]{4}) (\d{6})(?: ([A-Z]{3}))?$`)
+	wmoBBBPattern     = regexp.MustCompile(`^(?:(?:RR|CC|AA)[A-X]|P[A-Z]{2})$`)
+)
+
+// wmoHeadingSearchLines is how many non-empty lines at the start of the text
+// are searched for the heading. The heading follows the optional sequence number.
+const wmoHeadingSearchLines = 3
+
+// WMOHeading is the WMO abbreviated heading which opens a product's text
+type WMOHeading struct {
+	TTAAii string // Six character data designator (e.g., SRUS83)
+	CCCC   string // Four character issuing center (e.g., KARX)
+	DDHHMM string // Day, hour and minute in UTC (e.g., 250220)
+	BBB    string // Empty for the original issuance (e.g., RRA, CCA, AAB)
+}
+
+// ParseWMOHeading parses a single WMO abbreviated heading line
+func ParseWMOHeading(line string) (*WMOHeading, error) {
+	match := wmoHeadingPattern.FindStringSubmatch(strings.Join(strings.Fields(line), " "))
+	if match == nil {
+		return nil, fmt.Errorf("invalid WMO heading: %q", line)
+	}
+
+	heading := &WMOHeading{
+		TTAAii: match[1],
+		CCCC:   match[2],
+		DDHHMM: match[3],
+		BBB:    match[4],
+	}
+	if heading.BBB != "" && !wmoBBBPattern.MatchString(heading.BBB) {
+		return nil, fmt.Errorf("invalid WMO heading BBB indicator: %q", heading.BBB)
+	}
+	if _, err := heading.dayTime(); err != nil {
+		return nil, err
+	}
+	return heading, nil
+}
+
+// FindWMOHeading finds the WMO abbreviated heading at the start of a
+// product's text, skipping blank lines and the leading sequence number
+func FindWMOHeading(text string) (*WMOHeading, error) {
+	searched := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if heading, err := ParseWMOHeading(line); err == nil {
+			return heading, nil
+		}
+		searched++
+		if searched >= wmoHeadingSearchLines {
+			break
+		}
+	}
+	return nil, fmt.Errorf("no WMO heading found in product text")
+}
+
+// ParseWMOHeading finds the WMO abbreviated heading in the product text
+func (n *NWWSOIMessageXExtension) ParseWMOHeading() (*WMOHeading, error) {
+	return FindWMOHeading(n.Text)
+}
+
+// IsDelayed reports whether the product is a delayed or additional issuance (RRx)
+func (h *WMOHeading) IsDelayed() bool {
+	return strings.HasPrefix(h.BBB, "RR")
+}
+
+// IsCorrection reports whether the product corrects an earlier issuance (CCx)
+func (h *WMOHeading) IsCorrection() bool {
+	return strings.HasPrefix(h.BBB, "CC")
+}
+
+// IsAmendment reports whether the product amends an earlier issuance (AAx)
+func (h *WMOHeading) IsAmendment() bool {
+	return strings.HasPrefix(h.BBB, "AA")
+}
+
+// IsSegment reports whether the product is one part of a segmented issuance (Pxx)
+func (h *WMOHeading) IsSegment() bool {
+	return strings.HasPrefix(h.BBB, "P")
+}
+
+// Supersedes reports whether the product replaces an earlier version of
+// itself, which corrections and amendments do
+func (h *WMOHeading) Supersedes() bool {
+	return h.IsCorrection() || h.IsAmendment()
+}
+
+// Key returns the heading without the BBB indicator. Every version of a
+// product shares the same key.
+func (h *WMOHeading) Key() string {
+	return fmt.Sprintf("%s %s %s", h.TTAAii, h.CCCC, h.DDHHMM)
+}
+
+// String returns the heading as it appears in the product
+func (h *WMOHeading) String() string {
+	if h.BBB == "" {
+		return h.Key()
+	}
+	return h.Key() + " " + h.BBB
+}
+
+// Time resolves DDHHMM into a full time using the month and year of the
+// reference time, normally the issue time. A day after the reference day
+// belongs to the previous month. The zero time is returned when DDHHMM isn't
+// valid.
+func (h *WMOHeading) Time(reference time.Time) time.Time {
+	day, err := h.dayTime()
+	if err != nil || reference.IsZero() {
+		return time.Time{}
+	}
+
+	reference = reference.UTC()
+	month := reference.Month()
+	if day[0] > reference.Day() {
+		month--
+	}
+	return time.Date(reference.Year(), month, day[0], day[1], day[2], 0, 0, time.UTC)
+}
+
+// dayTime splits DDHHMM into its day, hour and minute
+func (h *WMOHeading) dayTime() ([3]int, error) {
+	var result [3]int
+	if len(h.DDHHMM) != 6 || !isDigits(h.DDHHMM) {
+		return result, fmt.Errorf("invalid WMO heading time: %q", h.DDHHMM)
+	}
+	for i := range result {
+		value, err := strconv.Atoi(h.DDHHMM[i*2 : i*2+2])
+		if err != nil {
+			return result, fmt.Errorf("invalid WMO heading time: %q", h.DDHHMM)
+		}
+		result[i] = value
+	}
+	if result[0] < 1 || result[0] > 31 || result[1] > 23 || result[2] > 59 {
+		return result, fmt.Errorf("invalid WMO heading time: %q", h.DDHHMM)
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package nwwsio_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestWMOHeadingTime(t *testing.T) {
+	reference := time.Date(2026, 5, 6, 22, 14, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		ddhhmm string
+		want   time.Time
+	}{
+		{name: "same day", ddhhmm: "062214", want: time.Date(2026, 5, 6, 22, 14, 0, 0, time.UTC)},
+		{name: "previous month", ddhhmm: "302359", want: time.Date(2026, 4, 30, 23, 59, 0, 0, time.UTC)},
+		{name: "empty", ddhhmm: ""},
+		{name: "short", ddhhmm: "0622"},
+		{name: "long", ddhhmm: "0622140"},
+		{name: "sign", ddhhmm: "+62214"},
+		{name: "letters", ddhhmm: "06221A"},
+		{name: "day zero", ddhhmm: "002214"},
+		{name: "hour out of range", ddhhmm: "062414"},
+		{name: "minute out of range", ddhhmm: "062260"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			heading := nwwsio.WMOHeading{TTAAii: "WFUS54", CCCC: "KOUN", DDHHMM: test.ddhhmm}
+			if got := heading.Time(reference); !got.Equal(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	var zero nwwsio.WMOHeading
+	if got := zero.Time(reference); !got.IsZero() {
+		t.Errorf("zero heading: got %v", got)
+	}
+}
@@ -46,6 +46,9 @@ Example Message Format:
 </message>
 */
 
+// NWWSOIMessageXExtension is the nwws-oi <x> element carrying a product in a
+// groupchat message. Importing this package registers it with the stanza
+// type registry so it is decoded into message extensions.
 type NWWSOIMessageXExtension struct {
 	stanza.MsgExtension
 	XMLName xml.Name `xml:"nwws-oi x"`
@@ -125,6 +128,7 @@ type DataEntry struct {
 	Priority []PriorityLevel
 }
 
+// PriorityLevel is a WMO message priority
 type PriorityLevel int
 
 const (
@@ -134,6 +138,7 @@ const (
 	Priority4 PriorityLevel = 4 // Administrative messages
 )
 
+// PriorityDescriptions describes each priority level
 var PriorityDescriptions = map[PriorityLevel]string{
 	Priority1: "Service messages",
 	Priority2: "Data and request messages",
@@ -171,6 +176,7 @@ var DataTable = []DataEntry{
 	{"Z", "-", "", "", "", "", nil},
 }
 
+// WMOProductID represents the parsed WMO data designator (T1T2A1A2ii)
 type WMOProductID struct {
 	T1 string
 	T2 string
@@ -179,6 +185,7 @@ type WMOProductID struct {
 	II string
 }
 
+// ParseTtaaii splits the six character WMO data designator into its parts
 func (n *NWWSOIMessageXExtension) ParseTtaaii() (*WMOProductID, error) {
 	if len(n.Ttaaii) != 6 {
 		return nil, fmt.Errorf("invalid Ttaaii length: expected 6, got %d", len(n.Ttaaii))
@@ -193,11 +200,10 @@ func (n *NWWSOIMessageXExtension) ParseTtaaii() (*WMOProductID, error) {
 	}, nil
 }
 
+// GetDataType returns the T1 data type from DataTable, or "Unknown"
 func (w *WMOProductID) GetDataType() string {
-	for _, entry := range DataTable {
-		if entry.T1 == w.T1 {
-			return entry.DataType
-		}
+	if entry, found := w.getDataEntry(); found {
+		return entry.DataType
 	}
 	return "Unknown"
 }
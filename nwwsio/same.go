@@ -0,0 +1,88 @@
+package nwwsio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/nwr/counties
+* https://www.weather.gov/nwr/nwrsame
+
+SAME Location Code Format:
+PSSCCC
+
+P   - Portion of the county, 0 for the entire county, 1-9 for a subdivision
+SS  - State FIPS code
+CCC - County FIPS code, 000 for the entire state
+
+Example:
+026163 - All of Wayne County, Michigan
+026000 - All of Michigan
+*/
+
+// SAMECode is a decoded SAME/FIPS location code
+type SAMECode struct {
+	Portion int // 0 for the entire county, 1-9 for a portion
+	State   int // State FIPS code
+	County  int // County FIPS code, 0 for the entire state
+}
+
+// ParseSAMECode parses a six digit SAME location code (e.g., 026163)
+func ParseSAMECode(code string) (SAMECode, error) {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 || !isDigits(code) {
+		return SAMECode{}, fmt.Errorf("invalid SAME code %q: expected 6 digits", code)
+	}
+
+	portion, _ := strconv.Atoi(code[0:1])
+	state, _ := strconv.Atoi(code[1:3])
+	county, _ := strconv.Atoi(code[3:6])
+	if state == 0 {
+		return SAMECode{}, fmt.Errorf("invalid SAME code %q: state FIPS cannot be 00", code)
+	}
+
+	return SAMECode{Portion: portion, State: state, County: county}, nil
+}
+
+// String returns the six digit code (e.g., 026163)
+func (s SAMECode) String() string {
+	return fmt.Sprintf("%d%02d%03d", s.Portion, s.State, s.County)
+}
+
+// IsStatewide reports whether the code covers an entire state
+func (s SAMECode) IsStatewide() bool {
+	return s.County == 0
+}
+
+// Matches reports whether two codes overlap the same way a weather radio
+// programmed with one would alert for the other: the states must match, a
+// county of 000 covers the whole state and a portion of 0 covers every
+// portion of the county
+func (s SAMECode) Matches(other SAMECode) bool {
+	if s.State != other.State {
+		return false
+	}
+	if s.IsStatewide() || other.IsStatewide() {
+		return true
+	}
+	if s.County != other.County {
+		return false
+	}
+	return s.Portion == other.Portion || s.Portion == 0 || other.Portion == 0
+}
+
+// GetSAME returns the decoded SAME geocodes from the area, skipping invalid codes
+func (a *Area) GetSAME() []SAMECode {
+	var result []SAMECode
+	for _, raw := range a.GetAllSAMECodes() {
+		code, err := ParseSAMECode(raw)
+		if err != nil {
+			continue
+		}
+		result = append(result, code)
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package nwwsio_test
+
+import (
+	"testing"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestParseSAMECode(t *testing.T) {
+	tests := []struct {
+		code      string
+		want      nwwsio.SAMECode
+		wantErr   bool
+		statewide bool
+	}{
+		{code: "026163", want: nwwsio.SAMECode{State: 26, County: 163}},
+		{code: " 026163 ", want: nwwsio.SAMECode{State: 26, County: 163}},
+		{code: "326163", want: nwwsio.SAMECode{Portion: 3, State: 26, County: 163}},
+		{code: "026000", want: nwwsio.SAMECode{State: 26}, statewide: true},
+		{code: "", wantErr: true},
+		{code: "26163", wantErr: true},
+		{code: "0261630", wantErr: true},
+		{code: "000163", wantErr: true},
+		{code: "02616A", wantErr: true},
+		{code: "-26163", wantErr: true},
+		{code: "+26163", wantErr: true},
+		{code: "02 163", wantErr: true},
+		{code: "MIC163", wantErr: true},
+		{code: "٠٢٦١٦٣", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code, func(t *testing.T) {
+			code, err := nwwsio.ParseSAMECode(test.code)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("got %s, want an error", code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if code != test.want || code.IsStatewide() != test.statewide {
+				t.Errorf("got %+v (statewide %v), want %+v (statewide %v)", code, code.IsStatewide(), test.want, test.statewide)
+			}
+		})
+	}
+}
+
+func TestSAMECodeMatches(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"026163", "026163", true},
+		{"026163", "026125", false},
+		{"026163", "040163", false},
+		{"026000", "026163", true},
+		{"026163", "026000", true},
+		{"026163", "326163", true},
+		{"326163", "126163", false},
+		{"026000", "040000", false},
+	}
+
+	for _, test := range tests {
+		a, errA := nwwsio.ParseSAMECode(test.a)
+		b, errB := nwwsio.ParseSAMECode(test.b)
+		if errA != nil || errB != nil {
+			t.Fatalf("failed to parse %s or %s", test.a, test.b)
+		}
+		if got := a.Matches(b); got != test.want {
+			t.Errorf("%s matches %s: got %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
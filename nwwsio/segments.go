@@ -0,0 +1,239 @@
+package nwwsio
+
+import (
+	"strings"
+	"time"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/media/directives/010_pdfs/pd01017001curr.pdf (NWSI 10-1701)
+
+Segmented Product Format:
+Products covering several areas with different hazards (WSW, NPW, FLW, SVS and
+others) repeat a block of UGC, VTEC, headline and body for each group of
+areas. Each segment ends with a line holding only "$$" and the forecaster's
+name or office follows the last one.
+
+Example:
+WWUS43 KDTX 150900
+WSWDTX
+
+URGENT - WINTER WEATHER MESSAGE
+National Weather Service Detroit/Pontiac MI
+400 AM EST Mon Jan 15 2026
+
+MIZ047>049-151700-
+/O.EXT.KDTX.WW.Y.0003.000000T0000Z-260115T1700Z/
+Midland-Bay-Huron-
+400 AM EST Mon Jan 15 2026
+
+...WINTER WEATHER ADVISORY REMAINS IN EFFECT UNTIL NOON EST TODAY...
+
+* WHAT...Snow. Additional snow accumulations of 1 to 2 inches.
+
+$$
+
+MIZ053>055-151700-
+...
+
+$$
+
+SMITH
+*/
+
+// segmentTerminator is the line which ends each segment
+const segmentTerminator = "$$"
+
+// Segment is one $$ terminated part of a product
+type Segment struct {
+	UGC      *UGCGroup // nil when the segment has no UGC block
+	VTEC     []VTEC
+	Headline string // First ...HEADLINE... without the dots, empty if there is none
+	Body     string // Text after the UGC and VTEC lines
+	Text     string // Full text of the segment without the terminator
+}
+
+// SegmentedProduct is a product's text split into its segments
+type SegmentedProduct struct {
+	Header    string // Text before the first segment, including the WMO heading and AWIPS ID
+	Segments  []Segment
+	Signature string // Text after the last segment
+}
+
+// SplitSegments splits a product's text into its header, segments and
+// signature. Products without a UGC block are returned with all of their text
+// in the header and no segments. UGC expirations are resolved relative to
+// reference, normally the issue time.
+func SplitSegments(text string, reference time.Time) *SegmentedProduct {
+	var chunks [][]string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == segmentTerminator {
+			chunks = append(chunks, current)
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+
+	product := &SegmentedProduct{}
+	if len(chunks) == 0 {
+		// Without a terminator the whole text is one segment
+		chunks = [][]string{current}
+	} else {
+		product.Signature = strings.TrimSpace(strings.Join(current, "\n"))
+	}
+
+	for i, chunk := range chunks {
+		start := findUGCStart(chunk)
+		if i == 0 {
+			if start < 0 {
+				start = len(chunk)
+			}
+			product.Header = strings.TrimSpace(strings.Join(chunk[:start], "\n"))
+			chunk = chunk[start:]
+			start = 0
+		}
+		if start < 0 {
+			start = 0
+		}
+
+		segmentText := strings.TrimSpace(strings.Join(chunk[start:], "\n"))
+		if segmentText == "" {
+			continue
+		}
+		product.Segments = append(product.Segments, parseSegment(chunk[start:], segmentText, reference))
+	}
+
+	if len(product.Segments) == 0 {
+		// Nothing looked like a segment, keep the text together
+		product.Header = strings.TrimSpace(text)
+		product.Signature = ""
+	}
+	return product
+}
+
+// findUGCStart returns the index of the line starting the first UGC group, or -1
+func findUGCStart(lines []string) int {
+	for i, line := range lines {
+		if ugcStartPattern.MatchString(strings.TrimSpace(line)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSegment decodes the UGC block, VTEC lines and headline of a segment
+func parseSegment(lines []string, text string, reference time.Time) Segment {
+	segment := Segment{Text: text, VTEC: FindVTEC(text)}
+
+	// Skip the UGC block, which may continue over several lines
+	body := 0
+	for body < len(lines) && strings.TrimSpace(lines[body]) == "" {
+		body++
+	}
+	if body < len(lines) && ugcStartPattern.MatchString(strings.TrimSpace(lines[body])) {
+		var ugcLines []string
+		for body < len(lines) {
+			line := strings.TrimSpace(lines[body])
+			if len(ugcLines) > 0 && !isUGCContinuation(line) {
+				break
+			}
+			ugcLines = append(ugcLines, line)
+			body++
+			if ugcEndPattern.MatchString(line) {
+				break
+			}
+		}
+		if group, err := ParseUGC(strings.Join(ugcLines, ""), reference); err == nil {
+			segment.UGC = group
+		}
+	}
+
+	// P-VTEC and H-VTEC lines follow the UGC block
+	for body < len(lines) {
+		line := strings.TrimSpace(lines[body])
+		if len(line) < 2 || !strings.HasPrefix(line, "/") || !strings.HasSuffix(line, "/") {
+			break
+		}
+		body++
+	}
+
+	segment.Body = strings.TrimSpace(strings.Join(lines[body:], "\n"))
+	segment.Headline = findHeadline(lines[body:])
+	return segment
+}
+
+// findHeadline returns the first ...HEADLINE... in the lines, which may wrap
+// over several lines, without the surrounding dots
+func findHeadline(lines []string) string {
+	var headline []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(headline) == 0 {
+			if !strings.HasPrefix(line, "...") {
+				continue
+			}
+		} else if line == "" {
+			// The headline was never closed
+			return ""
+		}
+
+		headline = append(headline, line)
+		if strings.HasSuffix(line, "...") && (len(headline) > 1 || len(line) > 6) {
+			return strings.Trim(strings.Join(headline, " "), ". ")
+		}
+	}
+	return ""
+}
+
+// Codes returns the UGC codes the segment covers
+func (s *Segment) Codes() []UGCCode {
+	if s.UGC == nil {
+		return nil
+	}
+	return s.UGC.Codes
+}
+
+// Covers reports whether the segment applies to the given county or zone
+func (s *Segment) Covers(code UGCCode) bool {
+	for _, covered := range s.Codes() {
+		if covered.Matches(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// Polygons returns the LAT...LON polygons in the segment
+func (s *Segment) Polygons() []Polygon {
+	return FindLatLonPolygons(s.Text)
+}
+
+// IsSegmented reports whether the product has more than one segment with a
+// UGC block, so different areas receive different text
+func (p *SegmentedProduct) IsSegmented() bool {
+	count := 0
+	for _, segment := range p.Segments {
+		if segment.UGC != nil {
+			count++
+		}
+	}
+	return count > 1
+}
+
+// Text rebuilds the product text with only the selected segments, keeping the
+// header and signature
+func (p *SegmentedProduct) Text(segments []int) string {
+	parts := []string{p.Header}
+	for _, i := range segments {
+		if i >= 0 && i < len(p.Segments) {
+			parts = append(parts, p.Segments[i].Text, segmentTerminator)
+		}
+	}
+	if p.Signature != "" {
+		parts = append(parts, p.Signature)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,326 @@
+package nwwsio
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/media/directives/010_pdfs/pd01005011curr.pdf (NWSI 10-511)
+* https://www.weather.gov/media/documentation/docs/NWS_CAP_v1.2_Profile.pdf
+
+Severe thunderstorm, tornado, flash flood and marine warnings and their
+statements end each segment with machine-readable tags:
+
+TIME...MOT...LOC 2102Z 245DEG 35KT 4231 8312 4220 8330
+
+TORNADO...RADAR INDICATED
+TORNADO DAMAGE THREAT...CONSIDERABLE
+MAX HAIL SIZE...1.75 IN
+MAX WIND GUST...70 MPH
+
+Marine warnings and older products use shorter forms such as "HAIL...>.75IN"
+and "WIND...>34KTS". TIME...MOT...LOC gives the direction the storm is moving
+from in degrees, its speed in knots and one or more positions in hundredths of
+a degree with longitude as degrees west. CAP carries the same tags as
+parameters (tornadoDetection, maxHailSize and so on).
+*/
+
+const knotsToMPH = 1.15078
+
+var (
+	stormMotionPattern = regexp.MustCompile(`^TIME\.\.\.MOT\.\.\.LOC (\d{4})Z (\d{1,3})DEG (\d{1,3})KT((?: \d{4} \d{4,5})*)$`)
+	hailSizePattern    = regexp.MustCompile(`^(?:MAX )?HAIL(?: SIZE)?\.\.\.([<>]?)(\d*\.?\d+) ?IN$`)
+	windGustPattern    = regexp.MustCompile(`^(?:MAX )?WIND(?: GUST)?\.\.\.([<>]?)(\d+) ?(MPH|KTS?)$`)
+	threatTagPattern   = regexp.MustCompile(`^(TORNADO|WATERSPOUT|FLASH FLOOD|HAIL THREAT|WIND THREAT|TORNADO DAMAGE THREAT|THUNDERSTORM DAMAGE THREAT|FLASH FLOOD DAMAGE THREAT)\.\.\.([A-Z ]+)$`)
+)
+
+// Detection sources in increasing order of confidence
+const (
+	DetectionPossible       = "POSSIBLE"
+	DetectionRadarIndicated = "RADAR INDICATED"
+	DetectionObserved       = "OBSERVED"
+)
+
+// Damage threats in increasing order of severity. Destructive is only used for
+// thunderstorms and catastrophic for tornadoes and flash floods.
+const (
+	DamageThreatConsiderable = "CONSIDERABLE"
+	DamageThreatDestructive  = "DESTRUCTIVE"
+	DamageThreatCatastrophic = "CATASTROPHIC"
+)
+
+var detectionRanks = map[string]int{
+	DetectionPossible:       1,
+	DetectionRadarIndicated: 2,
+	DetectionObserved:       3,
+}
+
+var damageThreatRanks = map[string]int{
+	DamageThreatConsiderable: 1,
+	DamageThreatDestructive:  2,
+	DamageThreatCatastrophic: 3,
+}
+
+// DetectionRank orders detection sources from 1 for POSSIBLE to 3 for
+// OBSERVED, or 0 when unknown
+func DetectionRank(detection string) int {
+	return detectionRanks[strings.ToUpper(detection)]
+}
+
+// DamageThreatRank orders damage threats from 1 for CONSIDERABLE to 3 for
+// CATASTROPHIC, or 0 when unknown
+func DamageThreatRank(threat string) int {
+	return damageThreatRanks[strings.ToUpper(threat)]
+}
+
+// StormMotion is a decoded TIME...MOT...LOC tag
+type StormMotion struct {
+	HHMM      string  // Time of the observation in UTC (e.g., 2102)
+	Direction int     // Degrees the storm is moving from
+	Speed     int     // Knots
+	Locations []Point // Storm positions, one for a single cell or several along a line
+}
+
+// Time resolves HHMM into a full time on the day of the reference time,
+// normally the issue time, moving to the previous day when it is later than
+// the reference. The zero time is returned when HHMM isn't valid.
+func (m *StormMotion) Time(reference time.Time) time.Time {
+	if len(m.HHMM) != 4 || !isDigits(m.HHMM) || reference.IsZero() {
+		return time.Time{}
+	}
+	hour, _ := strconv.Atoi(m.HHMM[:2])
+	minute, _ := strconv.Atoi(m.HHMM[2:])
+	if hour > 23 || minute > 59 {
+		return time.Time{}
+	}
+
+	reference = reference.UTC()
+	observed := time.Date(reference.Year(), reference.Month(), reference.Day(), hour, minute, 0, 0, time.UTC)
+	if observed.After(reference.Add(time.Hour)) {
+		observed = observed.AddDate(0, 0, -1)
+	}
+	return observed
+}
+
+// Heading returns the degrees the storm is moving towards
+func (m *StormMotion) Heading() int {
+	return (m.Direction + 180) % 360
+}
+
+// SpeedMPH returns the storm speed in miles per hour
+func (m *StormMotion) SpeedMPH() int {
+	return int(math.Round(float64(m.Speed) * knotsToMPH))
+}
+
+// String returns a compact description (e.g., "moving ENE at 40 mph")
+func (m *StormMotion) String() string {
+	if m.Speed == 0 {
+		return "stationary"
+	}
+	return fmt.Sprintf("moving %s at %d mph", CompassPoint(m.Heading()), m.SpeedMPH())
+}
+
+// CompassPoint returns the sixteen point compass direction for degrees
+func CompassPoint(degrees int) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	index := int(math.Round(float64(((degrees%360)+360)%360)/22.5)) % len(points)
+	return points[index]
+}
+
+// ThreatTags are the machine-readable tags at the end of a warning. Fields
+// are empty or zero when the tag is absent.
+type ThreatTags struct {
+	Motion *StormMotion
+
+	Tornado    string // POSSIBLE, RADAR INDICATED or OBSERVED
+	Waterspout string
+	FlashFlood string
+	HailThreat string
+	WindThreat string
+
+	TornadoDamageThreat      string // CONSIDERABLE or CATASTROPHIC
+	ThunderstormDamageThreat string // CONSIDERABLE or DESTRUCTIVE
+	FlashFloodDamageThreat   string // CONSIDERABLE or CATASTROPHIC
+
+	MaxHailSize float64 // Inches
+	MaxWindGust int     // Miles per hour, converted from knots for marine products
+}
+
+// FindThreatTags returns the tags found in a product's text, or nil if there
+// are none. Segmented products may repeat tags with different values, in which
+// case the most confident detection, the most severe damage threat and the
+// largest hail and wind are kept.
+func FindThreatTags(text string) *ThreatTags {
+	tags := &ThreatTags{}
+	found := false
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.Join(strings.Fields(strings.ToUpper(lines[i])), " ")
+
+		if strings.HasPrefix(line, "TIME...MOT...LOC") {
+			// Positions for a line of storms may wrap onto following lines
+			for i+1 < len(lines) && isLatLonContinuation(strings.TrimSpace(lines[i+1])) {
+				i++
+				line += " " + strings.Join(strings.Fields(lines[i]), " ")
+			}
+			if motion, err := ParseStormMotion(line); err == nil && tags.Motion == nil {
+				tags.Motion = motion
+				found = true
+			}
+			continue
+		}
+
+		if size, ok := parseHailSize(line); ok {
+			tags.MaxHailSize = math.Max(tags.MaxHailSize, size)
+			found = true
+			continue
+		}
+
+		if gust, ok := parseWindGust(line); ok {
+			tags.MaxWindGust = max(tags.MaxWindGust, gust)
+			found = true
+			continue
+		}
+
+		if match := threatTagPattern.FindStringSubmatch(line); match != nil {
+			if tags.setThreat(match[1], strings.TrimSpace(match[2])) {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return tags
+}
+
+// ParseStormMotion parses a TIME...MOT...LOC tag
+func ParseStormMotion(value string) (*StormMotion, error) {
+	match := stormMotionPattern.FindStringSubmatch(strings.Join(strings.Fields(value), " "))
+	if match == nil {
+		return nil, fmt.Errorf("invalid TIME...MOT...LOC: %q", value)
+	}
+
+	motion := &StormMotion{HHMM: match[1]}
+	motion.Direction, _ = strconv.Atoi(match[2])
+	motion.Speed, _ = strconv.Atoi(match[3])
+	if motion.Direction > 360 {
+		return nil, fmt.Errorf("invalid TIME...MOT...LOC direction: %d", motion.Direction)
+	}
+
+	fields := strings.Fields(match[4])
+	for i := 0; i+1 < len(fields); i += 2 {
+		lat, _ := strconv.Atoi(fields[i])
+		lon, _ := strconv.Atoi(fields[i+1])
+		motion.Locations = append(motion.Locations, Point{Lat: float64(lat) / 100, Lon: -float64(lon) / 100})
+	}
+	return motion, nil
+}
+
+// parseHailSize parses a MAX HAIL SIZE or HAIL tag, returning inches
+func parseHailSize(line string) (float64, bool) {
+	match := hailSizePattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	size, err := strconv.ParseFloat(match[2], 64)
+	return size, err == nil
+}
+
+// parseWindGust parses a MAX WIND GUST or WIND tag, returning miles per hour
+func parseWindGust(line string) (int, bool) {
+	match := windGustPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	gust, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, false
+	}
+	if strings.HasPrefix(match[3], "KT") {
+		gust = int(math.Round(float64(gust) * knotsToMPH))
+	}
+	return gust, true
+}
+
+// setThreat stores a detection or damage threat tag, keeping the highest
+// ranked value seen for each. Unknown values are only kept when nothing else
+// has been seen.
+func (t *ThreatTags) setThreat(tag, value string) bool {
+	var field *string
+	rank := DetectionRank
+	switch tag {
+	case "TORNADO":
+		field = &t.Tornado
+	case "WATERSPOUT":
+		field = &t.Waterspout
+	case "FLASH FLOOD":
+		field = &t.FlashFlood
+	case "HAIL THREAT":
+		field = &t.HailThreat
+	case "WIND THREAT":
+		field = &t.WindThreat
+	case "TORNADO DAMAGE THREAT":
+		field, rank = &t.TornadoDamageThreat, DamageThreatRank
+	case "THUNDERSTORM DAMAGE THREAT":
+		field, rank = &t.ThunderstormDamageThreat, DamageThreatRank
+	case "FLASH FLOOD DAMAGE THREAT":
+		field, rank = &t.FlashFloodDamageThreat, DamageThreatRank
+	default:
+		return false
+	}
+
+	if *field == "" || rank(value) > rank(*field) {
+		*field = value
+	}
+	return true
+}
+
+// MaxDamageThreat returns the most severe of the damage threat tags, or an
+// empty string when there are none
+func (t *ThreatTags) MaxDamageThreat() string {
+	threat := ""
+	for _, value := range []string{t.TornadoDamageThreat, t.ThunderstormDamageThreat, t.FlashFloodDamageThreat} {
+		if DamageThreatRank(value) > DamageThreatRank(threat) {
+			threat = value
+		}
+	}
+	return threat
+}
+
+// GetThreatTags returns the tags carried as CAP parameters, or nil if there
+// are none. CAP has no storm motion positions, so Motion is always nil.
+func (i *Info) GetThreatTags() *ThreatTags {
+	tags := &ThreatTags{
+		Tornado:                  strings.ToUpper(i.GetParameter("tornadoDetection")),
+		Waterspout:               strings.ToUpper(i.GetParameter("waterspoutDetection")),
+		FlashFlood:               strings.ToUpper(i.GetParameter("flashFloodDetection")),
+		HailThreat:               strings.ToUpper(i.GetParameter("hailThreat")),
+		WindThreat:               strings.ToUpper(i.GetParameter("windThreat")),
+		TornadoDamageThreat:      strings.ToUpper(i.GetParameter("tornadoDamageThreat")),
+		ThunderstormDamageThreat: strings.ToUpper(i.GetParameter("thunderstormDamageThreat")),
+		FlashFloodDamageThreat:   strings.ToUpper(i.GetParameter("flashFloodDamageThreat")),
+	}
+
+	// maxHailSize is given in inches without a unit (e.g., "1.75")
+	hail := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(i.GetParameter("maxHailSize"))), "IN")
+	if size, ok := parseHailSize("HAIL..." + strings.TrimSpace(hail) + " IN"); ok {
+		tags.MaxHailSize = size
+	}
+	if gust, ok := parseWindGust("WIND..." + strings.ToUpper(strings.TrimSpace(i.GetParameter("maxWindGust")))); ok {
+		tags.MaxWindGust = gust
+	}
+
+	if *tags == (ThreatTags{}) {
+		return nil
+	}
+	return tags
+}
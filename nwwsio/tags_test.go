@@ -0,0 +1,94 @@
+package nwwsio_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestFindThreatTagsAcrossSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []string
+		want     nwwsio.ThreatTags
+	}{
+		{
+			name: "later segment is more severe",
+			segments: []string{
+				"TORNADO...RADAR INDICATED\nTORNADO DAMAGE THREAT...CONSIDERABLE\nMAX HAIL SIZE...1.00 IN\n",
+				"TORNADO...OBSERVED\nTORNADO DAMAGE THREAT...CATASTROPHIC\nMAX HAIL SIZE...2.75 IN\n",
+			},
+			want: nwwsio.ThreatTags{Tornado: "OBSERVED", TornadoDamageThreat: "CATASTROPHIC", MaxHailSize: 2.75},
+		},
+		{
+			name: "earlier segment is more severe",
+			segments: []string{
+				"TORNADO...OBSERVED\nTORNADO DAMAGE THREAT...CATASTROPHIC\nMAX WIND GUST...80 MPH\n",
+				"TORNADO...POSSIBLE\nTORNADO DAMAGE THREAT...CONSIDERABLE\nMAX WIND GUST...60 MPH\n",
+			},
+			want: nwwsio.ThreatTags{Tornado: "OBSERVED", TornadoDamageThreat: "CATASTROPHIC", MaxWindGust: 80},
+		},
+		{
+			name: "damage threat only in one segment",
+			segments: []string{
+				"THUNDERSTORM DAMAGE THREAT...DESTRUCTIVE\nHAIL THREAT...RADAR INDICATED\n",
+				"HAIL THREAT...OBSERVED\n",
+				"THUNDERSTORM DAMAGE THREAT...CONSIDERABLE\n",
+			},
+			want: nwwsio.ThreatTags{ThunderstormDamageThreat: "DESTRUCTIVE", HailThreat: "OBSERVED"},
+		},
+		{
+			name: "flash flood",
+			segments: []string{
+				"FLASH FLOOD...RADAR INDICATED\nFLASH FLOOD DAMAGE THREAT...CONSIDERABLE\n",
+				"FLASH FLOOD...OBSERVED\nFLASH FLOOD DAMAGE THREAT...CATASTROPHIC\n",
+				"FLASH FLOOD...RADAR INDICATED\n",
+			},
+			want: nwwsio.ThreatTags{FlashFlood: "OBSERVED", FlashFloodDamageThreat: "CATASTROPHIC"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := ""
+			for _, segment := range test.segments {
+				text += "OKC027-062245-\n\n" + segment + "\n$$\n\n"
+			}
+
+			tags := nwwsio.FindThreatTags(text)
+			if tags == nil {
+				t.Fatal("no tags found")
+			}
+			if *tags != test.want {
+				t.Errorf("got %+v, want %+v", *tags, test.want)
+			}
+		})
+	}
+}
+
+func TestStormMotionTime(t *testing.T) {
+	reference := time.Date(2026, 5, 6, 0, 20, 0, 0, time.UTC)
+
+	tests := []struct {
+		hhmm string
+		want time.Time
+	}{
+		{hhmm: "0014", want: time.Date(2026, 5, 6, 0, 14, 0, 0, time.UTC)},
+		{hhmm: "2350", want: time.Date(2026, 5, 5, 23, 50, 0, 0, time.UTC)},
+		{hhmm: ""},
+		{hhmm: "1"},
+		{hhmm: "014"},
+		{hhmm: "00140"},
+		{hhmm: "+014"},
+		{hhmm: "2400"},
+		{hhmm: "0060"},
+	}
+
+	for _, test := range tests {
+		motion := nwwsio.StormMotion{HHMM: test.hhmm}
+		if got := motion.Time(reference); !got.Equal(test.want) {
+			t.Errorf("%q: got %v, want %v", test.hhmm, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package nwwsio
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/media/directives/010_pdfs/pd01017002curr.pdf (NWSI 10-1702)
+
+UGC Format:
+SSFNNN-NNN>NNN-SSFNNN-DDHHMM-
+
+SS     - Two letter state or marine area identifier
+F      - Format, C for county and Z for zone
+NNN    - Three digit county or zone number, 000 for all
+>      - Inclusive range of numbers
+DDHHMM - Product expiration day, hour and minute in UTC
+
+Example:
+MIZ060>063-068-069-075-
+162345-
+*/
+
+var (
+	// ugcStartPattern matches the first line of a UGC group
+	ugcStartPattern = regexp.MustCompile(`^[A-Z]{2}[CZ](\d{3}|ALL)[->]`)
+	// ugcEndPattern matches the DDHHMM expiration that terminates a UGC group
+	ugcEndPattern  = regexp.MustCompile(`\d{6}-$`)
+	ugcCodePattern = regexp.MustCompile(`^([A-Z]{2})([CZ])(\d{3})$`)
+)
+
+// UGCCode is a single decoded Universal Geographic Code
+type UGCCode struct {
+	State  string // Two letter state or marine area (e.g., MI, LH)
+	Type   string // C for county, Z for zone
+	Number int    // County/zone number, 0 means all
+}
+
+// ParseUGCCode parses a single six character UGC (e.g., MIC163)
+func ParseUGCCode(code string) (UGCCode, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	match := ugcCodePattern.FindStringSubmatch(code)
+	if match == nil {
+		return UGCCode{}, fmt.Errorf("invalid UGC code: %q", code)
+	}
+	number, _ := strconv.Atoi(match[3])
+	return UGCCode{State: match[1], Type: match[2], Number: number}, nil
+}
+
+// String returns the six character code (e.g., MIC163)
+func (u UGCCode) String() string {
+	return fmt.Sprintf("%s%s%03d", u.State, u.Type, u.Number)
+}
+
+// IsCounty reports whether the code is a county (C) code
+func (u UGCCode) IsCounty() bool {
+	return u.Type == "C"
+}
+
+// IsZone reports whether the code is a zone (Z) code
+func (u UGCCode) IsZone() bool {
+	return u.Type == "Z"
+}
+
+// Matches reports whether this code covers other, treating 000 as every
+// county or zone in the state
+func (u UGCCode) Matches(other UGCCode) bool {
+	if u.State != other.State || u.Type != other.Type {
+		return false
+	}
+	return u.Number == other.Number || u.Number == 0 || other.Number == 0
+}
+
+// UGCGroup is a decoded UGC header line with its expiration time
+type UGCGroup struct {
+	Codes   []UGCCode
+	Expires time.Time // Zero if the expiration could not be resolved
+	Raw     string
+}
+
+// ParseUGC parses a UGC group which may span multiple lines. The DDHHMM
+// expiration is resolved relative to reference, normally the issue time.
+func ParseUGC(raw string, reference time.Time) (*UGCGroup, error) {
+	compact := strings.Join(strings.Fields(raw), "")
+	if !ugcStartPattern.MatchString(compact) {
+		return nil, fmt.Errorf("invalid UGC line: %q", raw)
+	}
+
+	group := &UGCGroup{Raw: compact}
+	var state, format string
+
+	for _, token := range strings.Split(strings.TrimSuffix(compact, "-"), "-") {
+		if token == "" {
+			continue
+		}
+
+		// A six digit token is the DDHHMM expiration
+		if len(token) == 6 && isDigits(token) {
+			group.Expires = resolveUGCExpiration(token, reference)
+			continue
+		}
+
+		// A token may switch to a new state/format prefix
+		if len(token) >= 3 && isUpperAlpha(token[:2]) && (token[2] == 'C' || token[2] == 'Z') {
+			state, format = token[:2], string(token[2])
+			token = token[3:]
+		}
+		if state == "" {
+			return nil, fmt.Errorf("invalid UGC line: %q: number before state", raw)
+		}
+
+		codes, err := expandUGCRange(state, format, token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UGC line: %q: %w", raw, err)
+		}
+		group.Codes = append(group.Codes, codes...)
+	}
+
+	if len(group.Codes) == 0 {
+		return nil, fmt.Errorf("invalid UGC line: %q: no codes", raw)
+	}
+	return group, nil
+}
+
+// FindUGC returns every UGC group found in a product's text, joining groups
+// which continue over multiple lines
+func FindUGC(text string, reference time.Time) []UGCGroup {
+	var result []UGCGroup
+	var pending []string
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+
+		if len(pending) == 0 {
+			if !ugcStartPattern.MatchString(line) {
+				continue
+			}
+		} else if line == "" || !isUGCContinuation(line) {
+			// The group ended without an expiration, keep what we have
+			if group, err := ParseUGC(strings.Join(pending, ""), reference); err == nil {
+				result = append(result, *group)
+			}
+			pending = nil
+			if !ugcStartPattern.MatchString(line) {
+				continue
+			}
+		}
+
+		pending = append(pending, line)
+		if ugcEndPattern.MatchString(line) {
+			if group, err := ParseUGC(strings.Join(pending, ""), reference); err == nil {
+				result = append(result, *group)
+			}
+			pending = nil
+		}
+	}
+
+	if len(pending) > 0 {
+		if group, err := ParseUGC(strings.Join(pending, ""), reference); err == nil {
+			result = append(result, *group)
+		}
+	}
+
+	return result
+}
+
+// FindUGCCodes returns the unique UGC codes from every group in a product's text
+func FindUGCCodes(text string) []UGCCode {
+	var result []UGCCode
+	seen := make(map[UGCCode]bool)
+	for _, group := range FindUGC(text, time.Time{}) {
+		for _, code := range group.Codes {
+			if !seen[code] {
+				seen[code] = true
+				result = append(result, code)
+			}
+		}
+	}
+	return result
+}
+
+// isUGCContinuation reports whether a line continues a UGC group, which only
+// contains codes, digits, ranges and dashes
+func isUGCContinuation(line string) bool {
+	if !strings.HasSuffix(line, "-") && !strings.HasSuffix(line, ">") {
+		return false
+	}
+	for _, r := range line {
+		if !(r >= '0' && r <= '9') && !(r >= 'A' && r <= 'Z') && r != '-' && r != '>' {
+			return false
+		}
+	}
+	return true
+}
+
+// expandUGCRange expands a number or NNN>NNN range into individual codes
+func expandUGCRange(state, format, token string) ([]UGCCode, error) {
+	if token == "ALL" {
+		return []UGCCode{{State: state, Type: format, Number: 0}}, nil
+	}
+
+	start, end, isRange := strings.Cut(token, ">")
+	if !isRange {
+		end = start
+	}
+	// The end of a range may repeat the state and format prefix
+	if len(end) == 6 {
+		end = end[3:]
+	}
+
+	first, err := strconv.Atoi(start)
+	if err != nil || len(start) != 3 {
+		return nil, fmt.Errorf("invalid number %q", start)
+	}
+	last, err := strconv.Atoi(end)
+	if err != nil || len(end) != 3 {
+		return nil, fmt.Errorf("invalid number %q", end)
+	}
+	if last < first {
+		return nil, fmt.Errorf("invalid range %q", token)
+	}
+
+	codes := make([]UGCCode, 0, last-first+1)
+	for n := first; n <= last; n++ {
+		codes = append(codes, UGCCode{State: state, Type: format, Number: n})
+	}
+	return codes, nil
+}
+
+// resolveUGCExpiration converts DDHHMM into a full time using the month and
+// year of the reference time, rolling into the next month when needed
+func resolveUGCExpiration(ddhhmm string, reference time.Time) time.Time {
+	if reference.IsZero() {
+		return time.Time{}
+	}
+	day, _ := strconv.Atoi(ddhhmm[0:2])
+	hour, _ := strconv.Atoi(ddhhmm[2:4])
+	minute, _ := strconv.Atoi(ddhhmm[4:6])
+	if day < 1 || day > 31 || hour > 23 || minute > 59 {
+		return time.Time{}
+	}
+
+	reference = reference.UTC()
+	expires := time.Date(reference.Year(), reference.Month(), day, hour, minute, 0, 0, time.UTC)
+	if day < reference.Day() {
+		expires = time.Date(reference.Year(), reference.Month()+1, day, hour, minute, 0, 0, time.UTC)
+	}
+	return expires
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isUpperAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// GetUGC returns the decoded UGC geocodes from the area, skipping invalid codes
+func (a *Area) GetUGC() []UGCCode {
+	var result []UGCCode
+	for _, raw := range a.GetAllUGCCodes() {
+		code, err := ParseUGCCode(raw)
+		if err != nil {
+			continue
+		}
+		result = append(result, code)
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package nwwsio_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestParseUGC(t *testing.T) {
+	issued := time.Date(2026, 5, 30, 22, 14, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    string // codes, empty when an error is expected
+		expires time.Time
+	}{
+		{
+			name:    "single codes",
+			raw:     "OKC027-087-302245-",
+			want:    "[OKC027 OKC087]",
+			expires: time.Date(2026, 5, 30, 22, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "range",
+			raw:  "MIZ060>063-068-",
+			want: "[MIZ060 MIZ061 MIZ062 MIZ063 MIZ068]",
+		},
+		{
+			name: "range repeating the prefix",
+			raw:  "MIZ060>MIZ062-",
+			want: "[MIZ060 MIZ061 MIZ062]",
+		},
+		{
+			name:    "mixed counties and zones over several lines",
+			raw:     "OKC027-087-\nOKZ020>022-\nTXZ001-\n010600-",
+			want:    "[OKC027 OKC087 OKZ020 OKZ021 OKZ022 TXZ001]",
+			expires: time.Date(2026, 6, 1, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "whole state",
+			raw:     "LMZALL-302245-",
+			want:    "[LMZ000]",
+			expires: time.Date(2026, 5, 30, 22, 45, 0, 0, time.UTC),
+		},
+		{name: "empty", raw: ""},
+		{name: "lowercase", raw: "okc027-302245-"},
+		{name: "no state", raw: "027-087-302245-"},
+		{name: "bad format letter", raw: "OKX027-302245-"},
+		{name: "short number", raw: "OKC27-302245-"},
+		{name: "long number", raw: "OKC0270-302245-"},
+		{name: "backwards range", raw: "MIZ063>060-"},
+		{name: "open range", raw: "MIZ060>-"},
+		{name: "range to nothing", raw: "MIZ060>"},
+		{name: "letters in range", raw: "MIZ060>ABC-"},
+		{name: "only an expiration", raw: "302245-"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group, err := nwwsio.ParseUGC(test.raw, issued)
+			if test.want == "" {
+				if err == nil {
+					t.Errorf("got %v, want an error", group.Codes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if got := fmt.Sprint(group.Codes); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			if !group.Expires.Equal(test.expires) {
+				t.Errorf("got expiration %v, want %v", group.Expires, test.expires)
+			}
+		})
+	}
+}
+
+func TestFindUGC(t *testing.T) {
+	issued := time.Date(2026, 5, 6, 22, 14, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		text string
+		want []string // codes of each group
+	}{
+		{
+			name: "continuation lines",
+			text: "WWUS53 KOUN 062214\n\nOKC027-087-\nOKZ020>022-\n062245-\n\n/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/\n",
+			want: []string{"[OKC027 OKC087 OKZ020 OKZ021 OKZ022]"},
+		},
+		{
+			name: "one group per segment",
+			text: "OKC027-062245-\n\ntext\n\n$$\n\nOKZ020>021-062245-\n\ntext\n\n$$\n",
+			want: []string{"[OKC027]", "[OKZ020 OKZ021]"},
+		},
+		{
+			name: "group without an expiration",
+			text: "OKC027-087-\n\ntext\n",
+			want: []string{"[OKC027 OKC087]"},
+		},
+		{
+			name: "malformed group is skipped",
+			text: "OKC087>027-062245-\n\nOKC109-062245-\n",
+			want: []string{"[OKC109]"},
+		},
+		{
+			name: "text that looks like a start",
+			text: "OKC-\nMIZ\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, group := range nwwsio.FindUGC(test.text, issued) {
+				got = append(got, fmt.Sprint(group.Codes))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
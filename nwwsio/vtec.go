@@ -0,0 +1,279 @@
+package nwwsio
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Documentation:
+* https://www.weather.gov/media/directives/010_pdfs/pd01017003curr.pdf (NWSI 10-1703)
+* https://www.weather.gov/vtec/
+
+P-VTEC Format:
+/k.aaa.cccc.pp.s.####.yymmddThhnnZ-yymmddThhnnZ/
+
+k    - Product class (O, T, E, X)
+aaa  - Action (NEW, CON, EXT, ...)
+cccc - Issuing office
+pp   - Phenomena (TO, SV, FF, ...)
+s    - Significance (W, A, Y, ...)
+#### - Event tracking number (ETN)
+yymmddThhnnZ - Event beginning and ending date/time, 000000T0000Z when not applicable
+
+Example:
+/O.NEW.KDTX.TO.W.0012.251016T2100Z-251016T2145Z/
+*/
+
+// VTECTimeLayout is the Go time layout of the P-VTEC begin and end timestamps
+const VTECTimeLayout = "060102T1504Z"
+
+// vtecZeroTime is the P-VTEC placeholder for an unspecified begin or end time
+const vtecZeroTime = "000000T0000Z"
+
+var vtecPattern = regexp.MustCompile(`/([OTEX])\.([A-Z]{3})\.([A-Z]{4})\.([A-Z]{2})\.([A-Z])\.(\d{4})\.(\d{6}T\d{4}Z)-(\d{6}T\d{4}Z)/`)
+
+// VTECProductClasses maps the P-VTEC product class (k) to its description
+var VTECProductClasses = map[string]string{
+	"O": "Operational",
+	"T": "Test",
+	"E": "Experimental",
+	"X": "Experimental VTEC in Operational Product",
+}
+
+// VTECActions maps the P-VTEC action code (aaa) to its description
+var VTECActions = map[string]string{
+	"NEW": "New",
+	"CON": "Continued",
+	"EXT": "Extended in time",
+	"EXA": "Extended in area",
+	"EXB": "Extended in time and area",
+	"UPG": "Upgraded",
+	"CAN": "Cancelled",
+	"EXP": "Expired",
+	"COR": "Correction",
+	"ROU": "Routine",
+}
+
+// VTECSignificance maps the P-VTEC significance code (s) to its description
+var VTECSignificance = map[string]string{
+	"W": "Warning",
+	"A": "Watch",
+	"Y": "Advisory",
+	"S": "Statement",
+	"F": "Forecast",
+	"O": "Outlook",
+	"N": "Synopsis",
+}
+
+// VTECPhenomena maps the P-VTEC phenomena code (pp) to its description
+var VTECPhenomena = map[string]string{
+	"AF": "Ashfall",
+	"AS": "Air Stagnation",
+	"BH": "Beach Hazards",
+	"BS": "Blowing Snow",
+	"BW": "Brisk Wind",
+	"BZ": "Blizzard",
+	"CF": "Coastal Flood",
+	"CW": "Cold Weather",
+	"DF": "Debris Flow",
+	"DS": "Dust Storm",
+	"DU": "Blowing Dust",
+	"EC": "Extreme Cold",
+	"EH": "Excessive Heat",
+	"EQ": "Earthquake",
+	"EW": "Extreme Wind",
+	"FA": "Areal Flood",
+	"FF": "Flash Flood",
+	"FG": "Dense Fog",
+	"FL": "Flood",
+	"FR": "Frost",
+	"FW": "Fire Weather",
+	"FZ": "Freeze",
+	"GL": "Gale",
+	"HF": "Hurricane Force Wind",
+	"HT": "Heat",
+	"HU": "Hurricane",
+	"HW": "High Wind",
+	"HY": "Hydrologic",
+	"HZ": "Hard Freeze",
+	"IS": "Ice Storm",
+	"LE": "Lake Effect Snow",
+	"LO": "Low Water",
+	"LS": "Lakeshore Flood",
+	"LW": "Lake Wind",
+	"MA": "Marine",
+	"MF": "Marine Dense Fog",
+	"MH": "Marine Ashfall",
+	"MS": "Marine Dense Smoke",
+	"RB": "Small Craft for Rough Bar",
+	"RP": "Rip Current Risk",
+	"SC": "Small Craft",
+	"SE": "Hazardous Seas",
+	"SI": "Small Craft for Winds",
+	"SM": "Dense Smoke",
+	"SQ": "Snow Squall",
+	"SR": "Storm",
+	"SS": "Storm Surge",
+	"SU": "High Surf",
+	"SV": "Severe Thunderstorm",
+	"SW": "Small Craft for Hazardous Seas",
+	"TI": "Inland Tropical Storm",
+	"TO": "Tornado",
+	"TR": "Tropical Storm",
+	"TS": "Tsunami",
+	"TY": "Typhoon",
+	"UP": "Heavy Freezing Spray",
+	"WC": "Wind Chill",
+	"WI": "Wind",
+	"WS": "Winter Storm",
+	"WW": "Winter Weather",
+	"XH": "Extreme Heat",
+	"ZF": "Freezing Fog",
+	"ZR": "Freezing Rain",
+	"ZY": "Freezing Spray",
+}
+
+// VTEC represents a decoded P-VTEC (Primary Valid Time Event Code) string
+type VTEC struct {
+	ProductClass string // O, T, E or X
+	Action       string // NEW, CON, EXT, EXA, EXB, UPG, CAN, EXP, COR, ROU
+	Office       string // Four character issuing office (e.g., KDTX)
+	Phenomena    string // Two character phenomena code (e.g., TO)
+	Significance string // One character significance code (e.g., W)
+	ETN          int    // Event tracking number
+	Begin        time.Time
+	End          time.Time
+	Raw          string
+}
+
+// ParseVTEC parses a single P-VTEC string, with or without the enclosing slashes
+func ParseVTEC(raw string) (*VTEC, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "/") {
+		raw = "/" + raw
+	}
+	if !strings.HasSuffix(raw, "/") {
+		raw = raw + "/"
+	}
+
+	match := vtecPattern.FindStringSubmatch(raw)
+	if match == nil || match[0] != raw {
+		return nil, fmt.Errorf("invalid P-VTEC string: %q", raw)
+	}
+	return vtecFromMatch(match)
+}
+
+// FindVTEC returns every valid P-VTEC string found in a product's text in the
+// order they appear. Malformed codes are skipped.
+func FindVTEC(text string) []VTEC {
+	var result []VTEC
+	for _, match := range vtecPattern.FindAllStringSubmatch(text, -1) {
+		vtec, err := vtecFromMatch(match)
+		if err != nil {
+			continue
+		}
+		result = append(result, *vtec)
+	}
+	return result
+}
+
+func vtecFromMatch(match []string) (*VTEC, error) {
+	etn, err := strconv.Atoi(match[6])
+	if err != nil {
+		return nil, fmt.Errorf("invalid P-VTEC event tracking number (%s): %w", match[6], err)
+	}
+
+	begin, err := parseVTECTime(match[7])
+	if err != nil {
+		return nil, fmt.Errorf("invalid P-VTEC begin time (%s): %w", match[7], err)
+	}
+	end, err := parseVTECTime(match[8])
+	if err != nil {
+		return nil, fmt.Errorf("invalid P-VTEC end time (%s): %w", match[8], err)
+	}
+
+	return &VTEC{
+		ProductClass: match[1],
+		Action:       match[2],
+		Office:       match[3],
+		Phenomena:    match[4],
+		Significance: match[5],
+		ETN:          etn,
+		Begin:        begin,
+		End:          end,
+		Raw:          match[0],
+	}, nil
+}
+
+// parseVTECTime parses a P-VTEC timestamp, returning the zero time for 000000T0000Z
+func parseVTECTime(value string) (time.Time, error) {
+	if value == vtecZeroTime {
+		return time.Time{}, nil
+	}
+	return time.Parse(VTECTimeLayout, value)
+}
+
+// GetPhenomenaName returns a friendly name for the phenomena code
+func (v *VTEC) GetPhenomenaName() string {
+	if name, found := VTECPhenomena[v.Phenomena]; found {
+		return name
+	}
+	return v.Phenomena
+}
+
+// GetSignificanceName returns a friendly name for the significance code
+func (v *VTEC) GetSignificanceName() string {
+	if name, found := VTECSignificance[v.Significance]; found {
+		return name
+	}
+	return v.Significance
+}
+
+// GetActionName returns a friendly name for the action code
+func (v *VTEC) GetActionName() string {
+	if name, found := VTECActions[v.Action]; found {
+		return name
+	}
+	return v.Action
+}
+
+// GetEventName returns the combined phenomena and significance (e.g., "Tornado Warning")
+func (v *VTEC) GetEventName() string {
+	return fmt.Sprintf("%s %s", v.GetPhenomenaName(), v.GetSignificanceName())
+}
+
+// IsOperational reports whether the product class is operational
+func (v *VTEC) IsOperational() bool {
+	return v.ProductClass == "O"
+}
+
+// HasBegin reports whether the event has a specified beginning time
+func (v *VTEC) HasBegin() bool {
+	return !v.Begin.IsZero()
+}
+
+// HasEnd reports whether the event has a specified ending time
+func (v *VTEC) HasEnd() bool {
+	return !v.End.IsZero()
+}
+
+// String returns a human-readable summary (e.g., "Tornado Warning #12 from KDTX")
+func (v *VTEC) String() string {
+	return fmt.Sprintf("%s #%d from %s", v.GetEventName(), v.ETN, v.Office)
+}
+
+// GetVTEC returns all P-VTEC codes from the VTEC parameters of the info block
+func (i *Info) GetVTEC() []VTEC {
+	var result []VTEC
+	for _, param := range i.Parameter {
+		if param.ValueName != "VTEC" {
+			continue
+		}
+		result = append(result, FindVTEC(param.Value)...)
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package nwwsio_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seabird-chat/seabird-nwwsio-plugin/nwwsio"
+)
+
+func TestParseVTEC(t *testing.T) {
+	tests := []struct {
+		raw      string
+		want     string // String() of the parsed code, empty when an error is expected
+		action   string
+		hasBegin bool
+		hasEnd   bool
+		end      time.Time
+	}{
+		{
+			raw:      "/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/",
+			want:     "Tornado Warning #42 from KOUN",
+			action:   "NEW",
+			hasBegin: true,
+			hasEnd:   true,
+			end:      time.Date(2026, 5, 6, 22, 45, 0, 0, time.UTC),
+		},
+		{
+			// Slashes are optional
+			raw:      "O.EXT.KDTX.WW.Y.0003.000000T0000Z-260507T1800Z",
+			want:     "Winter Weather Advisory #3 from KDTX",
+			action:   "EXT",
+			hasBegin: false,
+			hasEnd:   true,
+			end:      time.Date(2026, 5, 7, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			// Open-ended events such as river floods have no begin or end
+			raw:    "/O.CON.KJAX.FL.W.0007.000000T0000Z-000000T0000Z/",
+			want:   "Flood Warning #7 from KJAX",
+			action: "CON",
+		},
+		{raw: ""},
+		{raw: "/"},
+		{raw: "//"},
+		{raw: "/O.NEW.KOUN.TO.W.0042/"},
+		{raw: "/O.NEW.KOUN.TO.W.42.260506T2214Z-260506T2245Z/"},
+		{raw: "/Q.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/"},
+		{raw: "/O.new.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/"},
+		{raw: "/O.NEW.OUN.TO.W.0042.260506T2214Z-260506T2245Z/"},
+		{raw: "/O.NEW.KOUN.TO.W.0042.260506T2214Z/"},
+		{raw: "/O.NEW.KOUN.TO.W.0042.260506T2214-260506T2245Z/"},
+		{raw: "/O.NEW.KOUN.TO.W.0042.261306T2214Z-260506T2245Z/"},
+		{raw: "/O.NEW.KOUN.TO.W.0042.260506T2214Z-260532T2245Z/"},
+		{raw: "/O.NEW.KOUN.TO.W.0042.260506T2514Z-260506T2245Z/"},
+		{raw: "/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/ trailing"},
+		{raw: "leading /O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/"},
+		{raw: "/KOUN.TO.W.0042.260506T2214Z-260506T2245Z/"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			vtec, err := nwwsio.ParseVTEC(test.raw)
+			if test.want == "" {
+				if err == nil {
+					t.Errorf("got %+v, want an error", vtec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if vtec.String() != test.want || vtec.Action != test.action {
+				t.Errorf("got %s %s, want %s %s", vtec.Action, vtec, test.action, test.want)
+			}
+			if vtec.HasBegin() != test.hasBegin || vtec.HasEnd() != test.hasEnd {
+				t.Errorf("got begin %v end %v, want begin %v end %v", vtec.HasBegin(), vtec.HasEnd(), test.hasBegin, test.hasEnd)
+			}
+			if !vtec.End.Equal(test.end) {
+				t.Errorf("got end %v, want %v", vtec.End, test.end)
+			}
+		})
+	}
+}
+
+func TestFindVTECSkipsMalformedCodes(t *testing.T) {
+	text := "OKC027-062245-\n" +
+		"/O.NEW.KOUN.TO.W.0042.260506T2214Z-260506T2245Z/\n" +
+		"/O.NEW.KOUN.SV.W.0101.260506T2214Z-269999T2245Z/\n" +
+		"/O.NEW.KOUN.SV.W.101.260506T2214Z-260506T2245Z/\n" +
+		"/O.CON.KOUN.SV.W.0100.000000T0000Z-260506T2300Z/\n" +
+		"/O.NEW.KOUN.TO.W.0043.260506T2214Z-\n\n$$\n"
+
+	var got []string
+	for _, vtec := range nwwsio.FindVTEC(text) {
+		got = append(got, vtec.Action+" "+vtec.String())
+	}
+	want := []string{"NEW Tornado Warning #42 from KOUN", "CON Severe Thunderstorm Warning #100 from KOUN"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}